@@ -0,0 +1,127 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// version is overridden at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+type aboutMsg struct {
+	text string
+}
+
+func loadAbout(caps capabilities) tea.Cmd {
+	return func() tea.Msg { return aboutMsg{aboutText(caps)} }
+}
+
+// aboutText gathers everything worth pasting into a bug report
+func aboutText(caps capabilities) string {
+	var b strings.Builder
+	line := func(label, value string) { fmt.Fprintf(&b, "%-16s %s\n", label+":", value) }
+
+	line("Version", version)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		line("Go", info.GoVersion)
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				line(strings.TrimPrefix(s.Key, "vcs."), s.Value)
+			}
+		}
+	}
+
+	switch l := backend.(type) {
+	case walkSearcher:
+		line("Backend", "built-in walker, no plocate, mlocate or locate in $PATH")
+	case locateSearcher:
+		path, err := exec.LookPath(l.command)
+		if l.host != "" {
+			path, err = l.host+":"+l.command, nil
+		}
+		if err != nil {
+			line("Backend", "no plocate, mlocate or locate in $PATH")
+		} else {
+			line("Backend", strings.TrimSpace(path+" "+caps.backendVersion))
+			line("Backend flags", fmt.Sprintf("-0 %s, regex %s, -b %s", yesNo(caps.null), yesNo(caps.regex), yesNo(caps.basename)))
+		}
+		if info, err := statPath(l.database); err != nil {
+			line("Database", err.Error())
+		} else {
+			line("Database", fmt.Sprintf("%s (%s, updated %s)", l.database,
+				formatSize(info.Size(), false), info.ModTime().Format("2006-01-02 15:04:05")))
+		}
+	case indexSearcher, daemonSearcher:
+		if d, ok := l.(daemonSearcher); ok {
+			line("Backend", "gocate daemon on "+d.socket)
+		} else {
+			line("Backend", "gocate index")
+		}
+		roots := indexRoots()
+		for _, root := range slices.Sorted(maps.Keys(roots)) {
+			if info, err := os.Stat(roots[root]); err == nil {
+				line("Index", fmt.Sprintf("%s (%s, updated %s)", root,
+					formatSize(info.Size(), false), info.ModTime().Format("2006-01-02 15:04:05")))
+			}
+		}
+	case databasesSearcher:
+		line("Backend", strings.TrimSpace(l.each[0].command+" "+caps.backendVersion))
+		for i, db := range l.each {
+			if info, err := statPath(db.database); err != nil {
+				line("Database", err.Error())
+			} else {
+				line("Database", fmt.Sprintf("%s (%s, updated %s)", l.labels[i],
+					formatSize(info.Size(), false), info.ModTime().Format("2006-01-02 15:04:05")))
+			}
+		}
+	default:
+		line("Backend", "fixture")
+	}
+	if f, ok := liveBackend.(fdSearcher); ok {
+		path, _ := exec.LookPath(f.command)
+		out, _ := exec.Command(f.command, "--version").Output()
+		first, _, _ := strings.Cut(string(out), "\n")
+		line("Live search", strings.TrimSpace(path+" "+first))
+	} else if liveBackend == nil {
+		line("Live search", "fd not found in $PATH")
+	}
+	if r, ok := contentBackend.(rgSearcher); ok {
+		path, _ := exec.LookPath(r.command)
+		out, _ := exec.Command(r.command, "--version").Output()
+		line("Content search", strings.TrimSpace(path+" "+firstLine(string(out))))
+	} else {
+		line("Content search", "rg not found in $PATH")
+	}
+	if path, err := configPath(); err == nil {
+		line("Config", path)
+	}
+	if path, err := auditPath(); err == nil {
+		line("Audit log", path)
+	}
+
+	line("Colours", lipgloss.ColorProfile().Name())
+	line("Kitty graphics", yesNo(caps.graphics))
+	line("OSC52", caps.osc52)
+	line("Clipboard", yesNo(caps.clipboard))
+	line("notify-send", yesNo(caps.notifySend))
+	line("pkexec", yesNo(caps.pkexec))
+	line("Opener", cmp.Or(caps.opener, "none"))
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
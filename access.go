@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+)
+
+// access is what this user may do with a result, judged from the owner, group
+// and mode of the stats already taken, the way the kernel would without ACLs,
+// so badging a row costs no more syscalls
+type access struct {
+	read, write bool
+	move        bool // delete or rename it, which its directory decides; true if that's unknown
+}
+
+// entryInfo is a result's stat with its directory's, for access.move
+type entryInfo struct {
+	os.FileInfo
+	dir os.FileInfo
+}
+
+// withDir pairs info with the stat of path's directory, looked up in dirs
+// when given so a search stats each directory once, and left alone if the
+// directory can't be stat'ed
+func withDir(path string, info os.FileInfo, dirs map[string]os.FileInfo) os.FileInfo {
+	dir := filepath.Dir(path)
+	dirInfo, ok := dirs[dir]
+	if !ok {
+		dirInfo, _ = statPath(dir)
+		if dirs != nil {
+			dirs[dir] = dirInfo
+		}
+	}
+	if dirInfo == nil {
+		return info
+	}
+	return entryInfo{info, dirInfo}
+}
+
+// permFor is the rwx bits of info's mode that apply to uid in groups
+func permFor(info os.FileInfo, st *syscall.Stat_t, uid int, groups []int) uint32 {
+	perm := uint32(info.Mode().Perm())
+	switch {
+	case int(st.Uid) == uid:
+		perm >>= 6
+	case slices.Contains(groups, int(st.Gid)):
+		perm >>= 3
+	}
+	return perm & 0o7
+}
+
+// userIDs are this process's uid and groups, read once
+var userIDs = sync.OnceValues(func() (int, []int) {
+	groups, _ := os.Getgroups()
+	return os.Getuid(), append(groups, os.Getgid())
+})
+
+// accessOf judges info, false for stats that carry no owner: fixtures, remote
+// hosts and what pkexec read
+func accessOf(info os.FileInfo) (access, bool) {
+	uid, groups := userIDs()
+	return accessAs(info, uid, groups)
+}
+
+// accessAs is accessOf for the user uid in groups
+func accessAs(info os.FileInfo, uid int, groups []int) (access, bool) {
+	if info == nil {
+		return access{}, false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return access{}, false
+	}
+	if uid == 0 {
+		return access{true, true, true}, true
+	}
+	perm := permFor(info, st, uid, groups)
+	a := access{read: perm&0o4 != 0, write: perm&0o2 != 0, move: true}
+	if e, ok := info.(entryInfo); ok {
+		if dst, ok := e.dir.Sys().(*syscall.Stat_t); ok {
+			// a sticky directory like /tmp also wants the file or the directory to be ours
+			sticky := e.dir.Mode()&os.ModeSticky != 0 && int(st.Uid) != uid && int(dst.Uid) != uid
+			a.move = permFor(e.dir, dst, uid, groups)&0o3 == 0o3 && !sticky
+		}
+	}
+	return a, true
+}
+
+// accessBadge goes in front of a result's name: 🔒 when this user can't read
+// it, 🔏 when they can but can't change, delete or rename it, nothing otherwise
+func accessBadge(info os.FileInfo) string {
+	a, ok := accessOf(info)
+	switch {
+	case !ok || a.read && a.write && a.move:
+		return ""
+	case !a.read:
+		return "🔒 "
+	default:
+		return "🔏 "
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAccessMove(t *testing.T) {
+	const stranger = 54321 // owns nothing here and is in none of its groups
+	for _, tc := range []struct {
+		dirMode, fileMode os.FileMode
+		want              access
+	}{
+		{0o777, 0o666, access{read: true, write: true, move: true}},
+		{0o755, 0o666, access{read: true, write: true}},                 // can write it but not delete it
+		{0o773, 0o644, access{read: true, move: true}},                  // -wx is enough for the directory
+		{0o776, 0o666, access{read: true, write: true}},                 // rw- isn't
+		{0o777 | os.ModeSticky, 0o666, access{read: true, write: true}}, // like /tmp, someone else's file
+	} {
+		dir := filepath.Join(t.TempDir(), "d")
+		path := filepath.Join(dir, "f")
+		if err := os.Mkdir(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, tc.fileMode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(dir, tc.dirMode); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := accessAs(withDir(path, info, nil), stranger, nil)
+		if !ok || got != tc.want {
+			t.Errorf("dir %v, file %v: %+v, want %+v", tc.dirMode, tc.fileMode, got, tc.want)
+		}
+		// without the directory's stat it's judged by the file alone
+		if got, _ := accessAs(info, stranger, nil); !got.move || got.write != tc.want.write {
+			t.Errorf("dir %v, file %v alone: %+v", tc.dirMode, tc.fileMode, got)
+		}
+		os.Chmod(dir, 0o700) // so TempDir can clean up
+	}
+}
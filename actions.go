@@ -0,0 +1,323 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+// mutatingActions change files on this machine or elsewhere, so -read-only refuses them
+var mutatingActions = map[string]bool{
+	"update_db":   true,
+	"checksum":    true, // writes the manifest
+	"send":        true,
+	"share":       true,
+	"pipe":        true, // the command could do anything
+	"batch":       true,
+	"permissions": true,
+}
+
+// do runs a named action, whether it came from a shortcut or a button. The
+// event that triggered it is used up and doesn't reach the input or table.
+func (m *model) do(action string) tea.Cmd {
+	if m.readOnly && mutatingActions[action] {
+		return notify(toastWarn, "Read-only mode: "+action+" is disabled")
+	}
+	if m.remote != "" && localActions[action] {
+		return notify(toastWarn, action+" works on this machine's files, not "+m.remote+"'s")
+	}
+	switch action {
+	case "quit":
+		return tea.Quit
+	case "units":
+		m.siUnit = !m.siUnit
+		m.lastQuery = ""
+	case "sort":
+		m.sortMode = (m.sortMode + 1) % sortModeCount
+		m.refreshRows()
+		return notify(toastInfo, "Sorted by "+m.sortName(m.sortMode))
+	case "profile":
+		return m.nextProfile()
+	case "update_db":
+		switch backend.(type) {
+		case indexSearcher, daemonSearcher: // the user's own, no sudo needed, and the daemon reloads it itself
+			m.statusMessage = "Updating the index…"
+			ignore := m.cfg.Ignore
+			return tea.Batch(m.jobs.runAudited(jobIndex, "gocate index", auditEntry{Action: "update_db"}, func(ctx context.Context, _ *auditEntry) (tea.Msg, error) {
+				err := reindexAll(ctx, ignore)
+				return updateDBMsg{err}, err
+			}), m.jobs.watchProgress())
+		}
+		c := exec.Command("bash", "-c", updatedbCommand)
+		return tea.ExecProcess(c, func(err error) tea.Msg {
+			// the callback runs on the event loop, so the log is left to a cmd
+			return tea.BatchMsg{func() tea.Msg { return logged(updateDBMsg{err}, auditEntry{Action: "update_db"}, err) }}
+		})
+	case "settings":
+		m.modals = m.modals.open(newSettingsDialog(m.cfg))
+	case "jobs":
+		d, cmd := newJobsDialog(m.jobs)
+		m.modals = m.modals.open(d)
+		return cmd
+	case "about":
+		return loadAbout(m.caps)
+	case "help":
+		m.modals = m.modals.open(textDialog{"Keys", m.help.FullHelpView(m.keys.FullHelp()) + "\n\n" + m.help.ShortHelpView([]key.Binding{closeDialog})})
+	case "copy":
+		row := m.selectedRow()
+		if m.pane.focused {
+			row = m.paneRow()
+		}
+		if row != nil {
+			m.copyPath(row[2])
+			m.rememberQuery() // quitting, so a failure has nowhere to show
+		}
+		return tea.Quit
+	case "switch_pane":
+		m.switchPane()
+	case "pane":
+		return m.togglePane()
+	case "focus":
+		m.setTableFocus(!m.tableFocused)
+		if m.tableFocused {
+			return m.rememberQuery()
+		}
+	case "mark":
+		if row := m.selectedRow(); row != nil {
+			m.toggleMark(idOf(row))
+			m.table.MoveDown(1)
+		}
+	case "checksum":
+		if paths := m.selection(); len(paths) > 0 {
+			m.modals = m.modals.open(m.newChecksumPrompt(paths))
+		}
+	case "diff":
+		if paths := m.markedPaths(); len(paths) == 2 {
+			m.statusMessage = "Comparing…"
+			return tea.Batch(m.jobs.run(jobHash, "diff "+filepath.Base(paths[0])+" "+filepath.Base(paths[1]), func(ctx context.Context) tea.Msg {
+				return compareFiles(ctx, paths[0], paths[1])
+			}), m.jobs.watchProgress())
+		}
+		return notify(toastWarn, "Mark exactly two files to compare them")
+	case "send":
+		paths := m.selection()
+		argv, err := expandTemplate(m.cfg.SendCommand, paths)
+		if err != nil {
+			return notify(toastWarn, "Set send_command in the config to send files")
+		}
+		if m.sending {
+			return nil
+		}
+		m.sending = true
+		m.statusMessage = "Sending…"
+		return tea.Batch(runTransfer(m.jobs, argv, paths), m.jobs.watchProgress())
+	case "share":
+		if paths := m.selection(); len(paths) > 0 {
+			return share(m.cfg, paths)
+		}
+		return nil
+	case "siblings":
+		if row := m.selectedRow(); row != nil {
+			m.textInput.SetValue("parent:" + quoteValue(filepath.Dir(row[2])))
+			m.textInput.CursorEnd()
+		}
+	case "elevate":
+		return m.elevate()
+	case "save_search":
+		if strings.TrimSpace(m.searchQuery) != "" {
+			m.modals = m.modals.open(newSaveSearchDialog(m.currentSearch()))
+		}
+	case "searches":
+		m.modals = m.modals.open(newSearchesDialog(m.cfg.Searches))
+	case "history":
+		m.modals = m.modals.open(newHistoryDialog(m.queryHistory))
+	case "root":
+		m.modals = m.modals.open(m.newRootDialog())
+	case "ignore":
+		m.modals = m.modals.open(m.newIgnoreDialog())
+	case "pipe":
+		if len(m.rows) > 0 {
+			m.modals = m.modals.open(newPipeDialog())
+		}
+	case "batch":
+		if paths := m.selection(); len(paths) > 0 && !m.batchRunning {
+			m.modals = m.modals.open(newBatchPrompt(paths))
+		}
+	case "permissions":
+		if paths := m.selection(); len(paths) > 0 {
+			m.modals = m.modals.open(newPermsPrompt(paths))
+		}
+	case "match_mode":
+		m.matchMode = (m.matchMode + 1) % matchModeCount
+		m.lastQuery = "" // search again in the new mode
+		return notify(toastInfo, "Matching as "+matchModeNames[m.matchMode])
+	case "ignore_case":
+		m.ignoreCase = !m.ignoreCase
+		m.lastQuery = ""
+		if m.ignoreCase {
+			return notify(toastInfo, "Ignoring case")
+		}
+		return notify(toastInfo, "Matching case")
+	case "live":
+		if liveBackend == nil {
+			return notify(toastWarn, "Install fd for live search")
+		}
+		m.live = !m.live
+		m.lastQuery = ""
+		if m.live {
+			return notify(toastInfo, "Searching the filesystem live under "+cmp.Or(m.displayRoot(), "/"))
+		}
+		return notify(toastInfo, "Searching the locate database")
+	case "watch":
+		return m.toggleWatch()
+	case "content":
+		if contentBackend == nil {
+			return notify(toastWarn, "Install ripgrep (rg) to search file contents")
+		}
+		m.content = !m.content
+		m.lastQuery = ""
+		m.matchLine = matchLine{}
+		if m.content {
+			return notify(toastInfo, "Searching inside files under "+cmp.Or(m.displayRoot(), "~"))
+		}
+		return notify(toastInfo, "Searching file names")
+	case "basename":
+		m.basename = !m.basename
+		m.lastQuery = ""
+		if m.basename {
+			return notify(toastInfo, "Matching file names only")
+		}
+		return notify(toastInfo, "Matching whole paths")
+	case "compact":
+		m.compact = !m.compact
+		m.resizeColumns()
+		if !m.compact {
+			m.lastQuery = "" // fetch the sizes and times compact mode skipped
+		}
+	case "clear":
+		m.setTableFocus(false)
+		m.queryErr = ""
+		m.textInput.SetValue("")
+		m.searchQuery = ""
+		m.setResults(nil)
+	}
+	return nil
+}
+
+// setTableFocus moves the keyboard between the query and the results. The
+// input hides its cursor while the table has focus so it's clear where letters go.
+func (m *model) setTableFocus(on bool) {
+	m.tableFocused = on
+	m.pane.focused = false
+	m.typeAhead = typeAhead{seq: m.typeAhead.seq}
+	m.register = registerNone
+	if on {
+		m.textInput.Blur()
+	} else {
+		m.textInput.Focus()
+	}
+}
+
+// copyPath puts path on the clipboard, or prints it on exit if there's no clipboard tool
+func (m *model) copyPath(path string) {
+	path = m.copyTarget(path)
+	if !m.caps.clipboard || clipboard.WriteAll(path) != nil {
+		m.output = path
+	}
+}
+
+// quickSelect acts on the nth row currently on screen without moving the
+// cursor: it copies the path and quits like enter, or opens the file and
+// stays if the config says so
+func (m *model) quickSelect(n int) tea.Cmd {
+	i := m.firstVisibleRow() + n
+	if n < 0 || n >= m.table.Height() || i >= len(m.rows) {
+		return nil
+	}
+	path := m.rows[i][2]
+	remembered := m.rememberQuery()
+	if !m.cfg.QuickOpen {
+		m.copyPath(path)
+		return tea.Quit
+	}
+	if m.caps.opener == "" {
+		return notify(toastWarn, "Install xdg-open to open files")
+	}
+	if err := exec.Command(m.caps.opener, m.openTarget(path)).Start(); err != nil {
+		return notify(toastError, "Couldn't open "+path+": "+err.Error())
+	}
+	return tea.Batch(remembered, notify(toastInfo, "Opened "+filepath.Base(path)))
+}
+
+// firstVisibleRow finds which row the table has scrolled to the top. The table
+// doesn't export its scroll offset, so look for the first drawn line among the
+// rows that can be on screen with the cursor where it is.
+func (m model) firstVisibleRow() int {
+	lines := strings.SplitN(m.table.View(), "\n", 3)
+	if len(lines) < 3 {
+		return 0
+	}
+	first := ansi.Strip(strings.SplitN(lines[2], "\n", 2)[0])
+	drawn := m.table.Rows()
+	cursor := m.table.Cursor()
+	for i := max(cursor-m.table.Height()+1, 0); i <= cursor && i < len(drawn); i++ {
+		if strings.Contains(first, strings.TrimSpace(drawn[i][2])) && strings.Contains(first, drawn[i][1]) {
+			return i
+		}
+	}
+	return max(cursor-m.table.Height()+1, 0)
+}
+
+// quickActions are the buttons under the search input, for mouse users
+var quickActions = []struct{ label, action string }{
+	{"Update DB", "update_db"},
+	{"Sort", "sort"},
+	{"Profile", "profile"},
+	{"Units", "units"},
+	{"Settings", "settings"},
+	{"Help", "help"},
+}
+
+var buttonStyle = lipgloss.NewStyle().Padding(0, 1).
+	Foreground(lipgloss.Color("252")).Background(lipgloss.Color("238"))
+
+func buttonZone(action string) string {
+	return "button-" + action
+}
+
+// actionBar draws the buttons that fit in the window, the rest are left off
+// rather than wrapped onto the table; their keys still work
+func (m model) actionBar() string {
+	var buttons []string
+	width := -1
+	for _, b := range quickActions {
+		button := buttonStyle.Render(b.label)
+		if width += 1 + lipgloss.Width(button); m.width > 0 && width > m.width-2 {
+			break
+		}
+		buttons = append(buttons, zone.Mark(buttonZone(b.action), button))
+	}
+	return strings.Join(buttons, " ")
+}
+
+// clickedButton names the action of the button under a left click, if any
+func clickedButton(msg tea.MouseMsg) string {
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return ""
+	}
+	for _, b := range quickActions {
+		if zone.Get(buttonZone(b.action)).InBounds(msg) {
+			return b.action
+		}
+	}
+	return ""
+}
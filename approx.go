@@ -0,0 +1,164 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxApproximate is how many misspelled file names a search falls back to
+const maxApproximate = 20
+
+// relaxation loosens a query that found nothing, or reports it has nothing to try
+type relaxation func(ctx context.Context, q query) (query, bool)
+
+// approximately runs search, and if it finds nothing tries each relaxation in
+// turn until one does, marking those results approximate. A cancelled search
+// stops there like any other.
+func approximately(ctx context.Context, q query, search func(query) tea.Cmd, relaxations ...relaxation) tea.Cmd {
+	return func() tea.Msg {
+		msg := search(q)()
+		res, ok := msg.(searchResultsMsg)
+		if !ok || res.err != nil || res.total > 0 {
+			return msg
+		}
+		for _, relax := range relaxations {
+			relaxed, ok := relax(ctx, q)
+			if !ok {
+				continue
+			}
+			switch found := search(relaxed)().(type) {
+			case nil:
+				return nil // superseded
+			case searchResultsMsg:
+				if found.err == nil && found.total > 0 {
+					found.approximate = true
+					return found
+				}
+			}
+		}
+		return res
+	}
+}
+
+// ignoringCase retries a case-sensitive query without minding case
+func ignoringCase(_ context.Context, q query) (query, bool) {
+	if q.opts.ignoreCase {
+		return q, false
+	}
+	q.opts.ignoreCase = true
+	return q, true
+}
+
+// misspelled retries a single plain term as the file names in the index that
+// contain it give or take a typo or two, swapped letters counting as one.
+// Longer terms are allowed more typos.
+func misspelled(ctx context.Context, q query) (query, bool) {
+	if len(q.alts) != 1 || len(q.alts[0]) != 1 || q.opts.regex || q.opts.basicRegex {
+		return q, false
+	}
+	term := []rune(strings.ToLower(q.alts[0][0]))
+	if len(term) < 4 {
+		return q, false // a typo in three letters leaves too little to go on
+	}
+	allowed := 1
+	if len(term) >= 8 {
+		allowed = 2
+	}
+	type candidate struct {
+		name string
+		dist int
+	}
+	var close []candidate
+	for _, name := range indexNames(ctx) {
+		if d := typoDistance([]rune(strings.ToLower(name)), term); d <= allowed {
+			close = append(close, candidate{name, d})
+		}
+	}
+	if len(close) == 0 {
+		return q, false
+	}
+	slices.SortFunc(close, func(a, b candidate) int {
+		return cmp.Or(cmp.Compare(a.dist, b.dist), cmp.Compare(len(a.name), len(b.name)), strings.Compare(a.name, b.name))
+	})
+	var names []string
+	for _, c := range close[:min(len(close), maxApproximate)] {
+		names = append(names, regexp.QuoteMeta(c.name))
+	}
+	q.alts = [][]string{{"^(" + strings.Join(names, "|") + ")$"}} // one run for all of them
+	q.opts.regex, q.opts.basename, q.opts.ignoreCase = true, true, false
+	return q, true
+}
+
+// typoDistance is the fewest single letter edits, swapping two neighbours
+// included, that make term appear somewhere in name
+func typoDistance(name, term []rune) int {
+	// Rows of the optimal string alignment distance, where starting anywhere in
+	// name is free: prev2, prev and cur are for term[:i-2], term[:i-1], term[:i].
+	prev2 := make([]int, len(name)+1)
+	prev := make([]int, len(name)+1) // an empty term is in name at no cost
+	cur := make([]int, len(name)+1)
+	for i := 1; i <= len(term); i++ {
+		cur[0] = i
+		for j := 1; j <= len(name); j++ {
+			cost := 1
+			if term[i-1] == name[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && term[i-1] == name[j-2] && term[i-2] == name[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return slices.Min(prev)
+}
+
+// nameIndex is every distinct file name in the locate database, read once
+// when a search first needs to guess at a misspelling
+var nameIndex struct {
+	sync.Mutex
+	names []string
+}
+
+// indexNames lists the distinct file names in the index, reading it the first
+// time. A cancelled read isn't kept.
+func indexNames(ctx context.Context) []string {
+	nameIndex.Lock()
+	defer nameIndex.Unlock()
+	if nameIndex.names != nil {
+		return nameIndex.names
+	}
+	results, err := backend.Search(ctx, []string{matchAll}, searchOpts{})
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var names []string
+	for r := range results {
+		if name := filepath.Base(r.path); r.err == nil && !seen[name] && utf8.ValidString(name) {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	nameIndex.names = names
+	return names
+}
+
+// forgetIndexNames drops the names read from the index once it's been updated
+func forgetIndexNames() {
+	nameIndex.Lock()
+	nameIndex.names = nil
+	nameIndex.Unlock()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// auditEntry is one line of the audit log, written for every mutatingAction once it finishes
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Paths   []string  `json:"paths,omitempty"`  // the files acted on
+	Target  string    `json:"target,omitempty"` // where they went: a manifest, share dir or command
+	Outcome string    `json:"outcome"`          // "ok", "failed" or "cancelled"
+	Error   string    `json:"error,omitempty"`
+}
+
+// stateDir follows the XDG base directory spec for state, which logs and history are
+func stateDir() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "gocate"), nil
+}
+
+func auditPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.log"), nil
+}
+
+// appendAudit adds e to the log as a JSON line. The file is only ever opened
+// for appending, so earlier entries can't be rewritten through gocate.
+func appendAudit(e auditEntry) error {
+	path, err := auditPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// logged records how an action went and passes on msg, the action's result.
+// It's called from the cmd that did the work, so Update never waits on the
+// log, and if the log can't be written a warning goes along with msg.
+func logged(msg tea.Msg, e auditEntry, actionErr error) tea.Msg {
+	e.Time, e.Outcome = time.Now(), "ok"
+	if actionErr != nil {
+		e.Outcome, e.Error = "failed", actionErr.Error()
+		if errors.Is(actionErr, context.Canceled) {
+			e.Outcome = "cancelled"
+		}
+	}
+	if err := appendAudit(e); err != nil {
+		return tea.BatchMsg{func() tea.Msg { return msg }, notify(toastWarn, "Couldn't write the audit log: "+err.Error())}
+	}
+	return msg
+}
+
+// runAudited is run for a job that's an action in the audit log, which the
+// job writes as it ends, however it ends. fn can fill in e's target once it
+// knows it; one cancelled while it waited its turn is logged as it was given.
+func (jm *jobManager) runAudited(kind jobKind, label string, e auditEntry, fn func(ctx context.Context, e *auditEntry) (tea.Msg, error)) tea.Cmd {
+	started := false
+	run := jm.run(kind, label, func(ctx context.Context) tea.Msg {
+		started = true
+		msg, err := fn(ctx, &e)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return logged(msg, e, err)
+	})
+	return func() tea.Msg {
+		msg := run()
+		if !started {
+			return logged(msg, e, context.Canceled)
+		}
+		return msg
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// readAudit is every entry written to the audit log so far
+func readAudit(t *testing.T) []auditEntry {
+	t.Helper()
+	path, err := auditPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []auditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e auditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestRunAudited(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	jm := newJobManager()
+	type doneMsg struct{}
+
+	ok := jm.runAudited(jobPipe, "ok", auditEntry{Action: "pipe", Target: "wc"}, func(ctx context.Context, e *auditEntry) (tea.Msg, error) {
+		e.Target = "wc -l"
+		return doneMsg{}, nil
+	})
+	if msg := ok(); msg != (doneMsg{}) {
+		t.Errorf("ok job returned %#v", msg)
+	}
+	failed := jm.runAudited(jobPipe, "failed", auditEntry{Action: "pipe"}, func(ctx context.Context, _ *auditEntry) (tea.Msg, error) {
+		return doneMsg{}, errors.New("exit status 1")
+	})
+	failed()
+
+	// cancelled while it runs, and before it gets a turn
+	running := jm.runAudited(jobPipe, "running", auditEntry{Action: "pipe"}, func(ctx context.Context, _ *auditEntry) (tea.Msg, error) {
+		jm.cancel(jm.snapshot()[0].id)
+		return doneMsg{}, nil
+	})
+	if _, ok := running().(jobCancelledMsg); !ok {
+		t.Error("job cancelled while running didn't say so")
+	}
+	queued := jm.runAudited(jobPipe, "queued", auditEntry{Action: "pipe"}, func(ctx context.Context, _ *auditEntry) (tea.Msg, error) {
+		t.Error("a cancelled job ran")
+		return doneMsg{}, nil
+	})
+	jm.cancel(jm.snapshot()[0].id)
+	queued()
+
+	var got []string
+	for _, e := range readAudit(t) {
+		got = append(got, e.Outcome+" "+e.Target+" "+e.Error)
+	}
+	want := []string{"ok wc -l ", "failed  exit status 1", "cancelled  context canceled", "cancelled  context canceled"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+}
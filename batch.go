@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newBatchPrompt prompts for the command to run on each of paths, recalling earlier ones
+func newBatchPrompt(paths []string) promptDialog {
+	note := fmt.Sprintf("Runs once per file for %d files. {} path, {.} without extension, {/} name, {//} directory, {/.} name without extension", len(paths))
+	d := newPromptDialog("Run for each file", note, "$ ", "", func(template string) tea.Msg {
+		return batchCommandMsg{strings.TrimSpace(template), paths}
+	})
+	d.history = loadHistory("batch")
+	return d
+}
+
+// batchCommandMsg is the template typed into the batch prompt
+type batchCommandMsg struct {
+	template string
+	paths    []string
+}
+
+type batchStatus int
+
+const (
+	batchPending batchStatus = iota
+	batchRunning
+	batchOK
+	batchFailed
+)
+
+// batchMsg reports one file starting or finishing, or the whole run once done is set
+type batchMsg struct {
+	i      int
+	status batchStatus
+	detail string         // why it failed
+	next   <-chan tea.Msg // the next update, or the end with the audit log written
+
+	done        bool
+	ok, failed  int
+	elapsed     time.Duration
+	template    string
+	paths       []string
+	failedPaths []string
+}
+
+// expandBatch fills in a command template for one file the way GNU parallel
+// does: {} is the path, {.} the path without its extension, {/} the file name,
+// {//} the directory and {/.} the name without extension. Each is shell quoted.
+// A template without any gets the path appended.
+func expandBatch(template, path string) string {
+	name := filepath.Base(path)
+	r := strings.NewReplacer(
+		"{//}", shellQuote(filepath.Dir(path)),
+		"{/.}", shellQuote(strings.TrimSuffix(name, filepath.Ext(name))),
+		"{/}", shellQuote(name),
+		"{.}", shellQuote(strings.TrimSuffix(path, filepath.Ext(path))),
+		"{}", shellQuote(path),
+	)
+	if cmd := r.Replace(template); cmd != template {
+		return cmd
+	}
+	return template + " " + shellQuote(path)
+}
+
+// shellQuote makes s one word to sh, whatever it contains
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runBatch runs template for every path through sh, a file per CPU at a time,
+// streaming back each start and finish
+func runBatch(template string, paths []string) tea.Cmd {
+	return func() tea.Msg {
+		ch := make(chan tea.Msg)
+		start := time.Now()
+		go func() {
+			jobs := make(chan int)
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			var failedPaths []string
+			ok := 0
+			for range min(runtime.NumCPU(), len(paths)) {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range jobs {
+						ch <- batchMsg{i: i, status: batchRunning, next: ch}
+						out, err := exec.Command("sh", "-c", expandBatch(template, paths[i])).CombinedOutput()
+						msg := batchMsg{i: i, status: batchOK, next: ch}
+						mu.Lock()
+						if err != nil {
+							failedPaths = append(failedPaths, paths[i])
+							msg.status, msg.detail = batchFailed, err.Error()
+							if last := lastLine(out); last != "" { // the exit status alone says little
+								msg.detail += ": " + last
+							}
+						} else {
+							ok++
+						}
+						mu.Unlock()
+						ch <- msg
+					}
+				}()
+			}
+			for i := range paths {
+				jobs <- i
+			}
+			close(jobs)
+			wg.Wait()
+			var err error
+			if len(failedPaths) > 0 {
+				err = fmt.Errorf("failed for %s", strings.Join(failedPaths, ", "))
+			}
+			msg := batchMsg{done: true, ok: ok, failed: len(failedPaths), elapsed: time.Since(start), template: template, paths: paths, failedPaths: failedPaths}
+			ch <- logged(msg, auditEntry{Action: "batch", Paths: paths, Target: template}, err)
+		}()
+		return <-ch
+	}
+}
+
+// waitBatch reads the next update of a running batch
+func waitBatch(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg { return <-ch }
+}
+
+func lastLine(out []byte) string {
+	lines := bytes.Split(bytes.TrimSpace(out), []byte("\n"))
+	return string(bytes.TrimSpace(lines[len(lines)-1]))
+}
+
+// batchSummary is the report once every file has run
+func batchSummary(msg batchMsg) string {
+	return fmt.Sprintf("%d ok, %d failed in %s", msg.ok, msg.failed, formatElapsed(msg.elapsed))
+}
+
+// batchDialog shows every file of a batch with how it went, updated as they finish
+type batchDialog struct {
+	template string
+	paths    []string
+	status   []batchStatus
+	details  []string
+	summary  string
+	viewport viewport.Model
+}
+
+func newBatchDialog(template string, paths []string, width, height int) batchDialog {
+	d := batchDialog{
+		template: template,
+		paths:    paths,
+		status:   make([]batchStatus, len(paths)),
+		details:  make([]string, len(paths)),
+		viewport: viewport.New(max(width-8, 20), min(max(height-12, 5), len(paths))),
+	}
+	d.refresh()
+	return d
+}
+
+func (d batchDialog) Update(msg tea.Msg) (dialog, tea.Cmd) {
+	if msg, ok := msg.(batchMsg); ok {
+		if msg.done {
+			d.summary = batchSummary(msg)
+		} else {
+			d.status = append([]batchStatus(nil), d.status...)
+			d.details = append([]string(nil), d.details...)
+			d.status[msg.i], d.details[msg.i] = msg.status, msg.detail
+		}
+		d.refresh()
+		return d, nil
+	}
+	var cmd tea.Cmd
+	d.viewport, cmd = d.viewport.Update(msg)
+	return d, cmd
+}
+
+func (d *batchDialog) refresh() {
+	var b strings.Builder
+	for i, path := range d.paths {
+		switch d.status[i] {
+		case batchPending:
+			b.WriteString("· " + path)
+		case batchRunning:
+			b.WriteString("… " + path)
+		case batchOK:
+			b.WriteString(diffAddStyle.Render("✔") + " " + path)
+		case batchFailed:
+			b.WriteString(diffDelStyle.Render("✗ " + path + ": " + d.details[i]))
+		}
+		b.WriteString("\n")
+	}
+	d.viewport.SetContent(strings.TrimSuffix(b.String(), "\n"))
+}
+
+func (d batchDialog) View() string {
+	done := 0
+	for _, s := range d.status {
+		if s == batchOK || s == batchFailed {
+			done++
+		}
+	}
+	footer := fmt.Sprintf("%d of %d done", done, len(d.paths))
+	if d.summary != "" {
+		footer = d.summary
+	}
+	return dialogTitleStyle.Render("Running "+d.template) + "\n\n" + d.viewport.View() + "\n\n" + footer + "\n\n" +
+		help.New().ShortHelpView([]key.Binding{pagerScroll, closeDialog})
+}
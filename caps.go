@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// capabilities is what this machine turned out to support when gocate started,
+// so features can be hidden or explained up front instead of failing when used
+type capabilities struct {
+	backendVersion string // first line of locate --version, empty for a fixture
+	null           bool   // locate -0, which every search relies on
+	regex          bool   // locate --regex/--regexp, needed by every match mode but substring
+	basename       bool   // locate -b
+	clipboard      bool   // wl-clipboard, xsel or xclip for copying paths
+	notifySend     bool
+	pkexec         bool   // polkit, to stat what this user can't
+	opener         string // what opens a file in its default app, empty if nothing does
+	graphics       bool   // the terminal speaks the kitty graphics protocol
+	osc52          string // a guess, terminals don't answer a query for it
+}
+
+// probeCapabilities asks the backend what it supports and looks for the helper
+// commands and terminal features gocate can use. A fixture supports everything.
+func probeCapabilities() capabilities {
+	c := capabilities{null: true, regex: true, basename: true}
+	switch s := backend.(type) {
+	case locateSearcher:
+		c = s.probe()
+	case databasesSearcher:
+		c = s.each[0].probe() // the same command for every database
+	}
+	c.clipboard = !clipboard.Unsupported
+	_, err := exec.LookPath("notify-send")
+	c.notifySend = err == nil
+	_, err = exec.LookPath("pkexec")
+	c.pkexec = err == nil
+	for _, opener := range []string{"xdg-open", "open"} {
+		if _, err := exec.LookPath(opener); err == nil {
+			c.opener = opener
+			break
+		}
+	}
+	c.graphics = os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("TERM") == "xterm-kitty" ||
+		os.Getenv("TERM_PROGRAM") == "WezTerm" || os.Getenv("TERM_PROGRAM") == "ghostty"
+	c.osc52 = osc52Support()
+	return c
+}
+
+// probe reads which flags the installed locate has from its --help, since
+// older plocate and some mlocate builds lack a few
+func (l locateSearcher) probe() capabilities {
+	var c capabilities
+	if out, err := l.cmd(context.Background(), "--version").Output(); err == nil {
+		first, _, _ := strings.Cut(string(out), "\n")
+		c.backendVersion = strings.TrimSpace(first)
+	}
+	out, _ := l.cmd(context.Background(), "--help").CombinedOutput() // some exit non-zero after printing it
+	help := string(out)
+	c.null = strings.Contains(help, "--null") || strings.Contains(help, "-0")
+	c.regex = strings.Contains(help, "--regex")
+	c.basename = strings.Contains(help, "--basename")
+	return c
+}
+
+// osc52Support can only guess, terminals don't answer a query for it
+func osc52Support() string {
+	switch {
+	case os.Getenv("TMUX") != "":
+		return "via tmux (needs set-clipboard on)"
+	case os.Getenv("KITTY_WINDOW_ID") != "", os.Getenv("WEZTERM_PANE") != "", os.Getenv("ALACRITTY_WINDOW_ID") != "",
+		os.Getenv("TERM_PROGRAM") == "iTerm.app", os.Getenv("TERM_PROGRAM") == "ghostty", strings.HasPrefix(os.Getenv("TERM"), "foot"):
+		return "likely"
+	}
+	return "unknown"
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const manifestName = "SHA256SUMS"
+
+// fileSum is one line of a manifest, or the error hashing that file
+type fileSum struct {
+	path, sum string
+	err       error
+}
+
+// checksumsMsg reports the hashes of the marked files and where the manifest went
+type checksumsMsg struct {
+	sums     []fileSum
+	paths    []string
+	manifest string
+	err      error
+}
+
+// hashFiles computes SHA-256 for every path, a file per CPU at a time, keeping
+// the input order, and reports the bytes read as the job's progress. Once ctx
+// is done the files left fail with its error.
+func hashFiles(ctx context.Context, paths []string) []fileSum {
+	var read atomic.Int64
+	total := int64(0)
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil {
+			total += info.Size()
+		}
+	}
+	sums := make([]fileSum, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				sums[i].path = paths[i]
+				sums[i].sum, sums[i].err = hashFile(progressReader{ctx: ctx, read: &read, total: total}, paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return sums
+}
+
+func hashFile(pr progressReader, path string) (string, error) {
+	if err := pr.ctx.Err(); err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	pr.r = f
+	if _, err := io.Copy(h, pr); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checksumDirMsg is the directory typed into the checksum prompt
+type checksumDirMsg struct {
+	dir   string
+	paths []string
+}
+
+// newChecksumPrompt asks where the manifest for paths goes, offering the
+// directory they're all in, which is also where it goes if left empty
+func (m model) newChecksumPrompt(paths []string) promptDialog {
+	note := fmt.Sprintf("Hashes %d files and writes %s there, or %s.2 and so on beside an earlier one", len(paths), manifestName, manifestName)
+	d := newPromptDialog("SHA-256", note, "Directory: ", m.displayPath(commonDir(paths)), func(dir string) tea.Msg {
+		if strings.TrimSpace(dir) == "" {
+			dir = commonDir(paths)
+		}
+		return checksumDirMsg{dir, paths}
+	})
+	d.complete = pathCompleter(m.home, true)
+	return d
+}
+
+// commonDir is the deepest directory every path is in
+func commonDir(paths []string) string {
+	dir := filepath.Dir(paths[0])
+	for _, p := range paths[1:] {
+		for dir != "/" && dir != "." && !strings.HasPrefix(p, dir+"/") {
+			dir = filepath.Dir(dir)
+		}
+	}
+	return dir
+}
+
+// manifestDir resolves the directory typed into the checksum prompt
+func manifestDir(dir, home string) (string, error) {
+	dir, err := filepath.Abs(expandHome(strings.TrimSpace(dir), home))
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s isn't a directory", dir)
+	}
+	return dir, nil
+}
+
+// checksumMarked hashes paths and writes a manifest sha256sum -c understands into
+// dir, next to any earlier one rather than over it
+func checksumMarked(ctx context.Context, paths []string, dir string) checksumsMsg {
+	sums := hashFiles(ctx, paths)
+	if err := ctx.Err(); err != nil { // cancelled, no manifest of the files hashed so far
+		return checksumsMsg{sums: sums, paths: paths, err: err}
+	}
+	var b strings.Builder
+	for _, s := range sums {
+		if s.err == nil {
+			fmt.Fprintf(&b, "%s  %s\n", s.sum, s.path)
+		}
+	}
+	name := filepath.Join(dir, manifestName)
+	for i := 2; ; i++ {
+		err := writeNew(name, b.String())
+		if !errors.Is(err, fs.ErrExist) {
+			return checksumsMsg{sums: sums, paths: paths, manifest: name, err: err}
+		}
+		name = filepath.Join(dir, fmt.Sprintf("%s.%d", manifestName, i))
+	}
+}
+
+// progressReader stops reading once ctx is done, so cancelling a hash of a
+// big file doesn't wait for the rest of it, holds off while the user types,
+// and reports what's been read of every file being hashed as it goes
+type progressReader struct {
+	ctx   context.Context
+	r     io.Reader
+	read  *atomic.Int64 // shared by the files hashed together
+	total int64
+}
+
+func (pr progressReader) Read(p []byte) (int, error) {
+	if err := pauseWhileTyping(pr.ctx); err != nil {
+		return 0, err
+	}
+	n, err := pr.r.Read(p)
+	reportProgress(pr.ctx, pr.read.Add(int64(n)), pr.total)
+	return n, err
+}
+
+func writeNew(name, data string) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// checksumsText lays out the result for a dialog
+func checksumsText(msg checksumsMsg) string {
+	var b strings.Builder
+	for _, s := range msg.sums {
+		if s.err != nil {
+			fmt.Fprintf(&b, "%s: %v\n", s.path, s.err)
+		} else {
+			fmt.Fprintf(&b, "%s  %s\n", s.sum[:16]+"…", s.path)
+		}
+	}
+	if msg.err != nil {
+		fmt.Fprintf(&b, "\nCouldn't write %s: %v", msg.manifest, msg.err)
+	} else {
+		fmt.Fprintf(&b, "\nWrote %s", msg.manifest)
+	}
+	return b.String()
+}
+
+// verifyManifest checks every file listed in a SHA256SUMS-style manifest,
+// printing the same OK/FAILED lines as sha256sum -c. It reports whether all matched.
+func verifyManifest(name string, out io.Writer) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	var paths, want []string
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if line == "" {
+			continue
+		}
+		sum, path, ok := strings.Cut(line, " ")
+		if !ok || len(sum) != sha256.Size*2 || len(path) < 2 || (path[0] != ' ' && path[0] != '*') {
+			return false, fmt.Errorf("%s:%d: expected \"<sha256>  <path>\"", name, n)
+		}
+		paths, want = append(paths, path[1:]), append(want, strings.ToLower(sum))
+	}
+	if err := sc.Err(); err != nil {
+		return false, err
+	}
+
+	ok := true
+	for i, s := range hashFiles(context.Background(), paths) {
+		switch {
+		case s.err != nil:
+			fmt.Fprintf(out, "%s: FAILED open or read (%v)\n", s.path, s.err)
+			ok = false
+		case s.sum != want[i]:
+			fmt.Fprintf(out, "%s: FAILED\n", s.path)
+			ok = false
+		default:
+			fmt.Fprintf(out, "%s: OK\n", s.path)
+		}
+	}
+	return ok, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommonDir(t *testing.T) {
+	for _, tc := range []struct {
+		paths []string
+		want  string
+	}{
+		{[]string{"/home/ann/a.txt"}, "/home/ann"},
+		{[]string{"/home/ann/docs/a.txt", "/home/ann/docs/b.txt"}, "/home/ann/docs"},
+		{[]string{"/home/ann/docs/a.txt", "/home/ann/docsy/b.txt"}, "/home/ann"},
+		{[]string{"/home/ann/a.txt", "/etc/hosts"}, "/"},
+	} {
+		if got := commonDir(tc.paths); got != tc.want {
+			t.Errorf("commonDir(%q) = %q, want %q", tc.paths, got, tc.want)
+		}
+	}
+}
+
+func TestChecksumMarkedDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(file, []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  " + file + "\n"
+	for _, name := range []string{"SHA256SUMS", "SHA256SUMS.2"} {
+		msg := checksumMarked(context.Background(), []string{file}, dir)
+		if msg.err != nil || msg.manifest != filepath.Join(dir, name) {
+			t.Fatalf("wrote %s, %v, want %s", msg.manifest, msg.err, name)
+		}
+		if got, _ := os.ReadFile(msg.manifest); string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// pathCompleter completes paths typed into a promptDialog
+func pathCompleter(home string, dirsOnly bool) func(string) string {
+	return func(typed string) string { return completePath(typed, home, dirsOnly) }
+}
+
+// completePath completes the last component of a typed path from the
+// filesystem, as far as the names it could be agree, with a / once it names a
+// single directory. ~ is read as home but kept as typed. Only directories are
+// offered with dirsOnly.
+func completePath(typed, home string, dirsOnly bool) string {
+	if typed == "~" {
+		return "~/"
+	}
+	dir, partial := "", typed
+	if i := strings.LastIndexByte(typed, '/'); i >= 0 {
+		dir, partial = typed[:i+1], typed[i+1:]
+	}
+	list := cmp.Or(expandHome(dir, home), ".")
+	entries, err := os.ReadDir(list)
+	if err != nil {
+		return typed
+	}
+	var names []string
+	single := ""
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), partial) || strings.HasPrefix(e.Name(), ".") && !strings.HasPrefix(partial, ".") {
+			continue
+		}
+		isDir := e.IsDir()
+		if e.Type()&os.ModeSymlink != 0 { // a link to a directory counts as one
+			info, err := os.Stat(filepath.Join(list, e.Name()))
+			isDir = err == nil && info.IsDir()
+		}
+		if dirsOnly && !isDir {
+			continue
+		}
+		names = append(names, e.Name())
+		if isDir {
+			single = e.Name() + "/"
+		} else {
+			single = e.Name()
+		}
+	}
+	switch len(names) {
+	case 0:
+		return typed
+	case 1:
+		return dir + single
+	}
+	return dir + commonPrefix(names)
+}
+
+// commonPrefix is the longest start every one of names shares
+func commonPrefix(names []string) string {
+	prefix := names[0]
+	for _, n := range names[1:] {
+		for !strings.HasPrefix(n, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	for !utf8.ValidString(prefix) { // cut between two names' different last runes
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// config is everything the settings screen can change, saved as JSON under the user config dir
+type config struct {
+	Theme        string              `json:"theme"`
+	ShowSize     bool                `json:"show_size"`
+	ShowModified bool                `json:"show_modified"`
+	DebounceMs   int                 `json:"debounce_ms"`
+	SIUnits      bool                `json:"si_units"`
+	QuickOpen    bool                `json:"quick_select_opens"`      // alt+1…9 open the file instead of copying it
+	HomePaths    bool                `json:"home_relative_paths"`     // show /home/me/x as ~/x
+	SendCommand  []string            `json:"send_command,omitempty"`  // e.g. ["rsync", "-a", "--info=progress2", "{files}", "host:dir/"]
+	ShareCommand []string            `json:"share_command,omitempty"` // runs instead of xdg-email, with {files} like send_command
+	ShareDir     string              `json:"share_dir,omitempty"`     // copy shared files here instead, e.g. a synced folder
+	Keys         map[string][]string `json:"keys,omitempty"`          // action name -> keys, overriding the defaults
+	Profiles     []profile           `json:"profiles,omitempty"`      // replace the built-in media/code profiles
+	Searches     []savedSearch       `json:"saved_searches,omitempty"`
+	Ignore       []string            `json:"ignore,omitempty"`         // file name globs left out of every search, e.g. node_modules or *.o
+	WalkDepth    int                 `json:"walk_max_depth,omitempty"` // how far below the root live search and the built-in walker go, 0 for no limit
+	Order        string              `json:"order,omitempty"`          // "name", "mtime" or "size" to rank every match before the first page, empty for the backend's order
+	FileTypes    []fileType          `json:"file_types,omitempty"`     // extension groups for type: clauses and icons, replacing built-in ones of the same name
+	Databases    []string            `json:"databases,omitempty"`      // locate databases searched together instead of the default one, read at start
+	ShowDatabase bool                `json:"show_database,omitempty"`  // a column naming the database each result came from, with several
+	UseIndex     bool                `json:"use_index,omitempty"`      // search what gocate index built even with a locate installed, read at start
+	WatchIndex   bool                `json:"watch_index,omitempty"`    // follow changes under the indexed directories while running, read at start
+	ZebraRows    bool                `json:"zebra_rows,omitempty"`     // every other row shaded
+	Density      string              `json:"row_density,omitempty"`    // "dense" or "spacious", empty for normal
+	Separators   bool                `json:"separators,omitempty"`     // a line where the directory changes from one row to the next
+	WatchSecs    int                 `json:"watch_seconds,omitempty"`  // how often a watched query runs again, 5 when unset
+}
+
+func defaultConfig() config {
+	return config{Theme: "blue", ShowSize: true, ShowModified: true}
+}
+
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gocate", "config.json"), nil
+}
+
+// loadConfig falls back to the defaults for a missing file or any field left out of it
+func loadConfig() (config, error) {
+	cfg := defaultConfig()
+	path, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaultConfig(), err
+	}
+	return cfg, nil
+}
+
+func saveConfig(cfg config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
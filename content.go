@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// rgSearcher finds files by what's in them rather than their names, running
+// ripgrep under the root. rg's own ignore rules apply, so .gitignored and
+// binary files are left out, unlike name searches.
+type rgSearcher struct {
+	command string
+}
+
+// contentBackend searches inside files, nil when rg isn't installed
+var contentBackend searcher
+
+func detectRg() (rgSearcher, bool) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		return rgSearcher{}, false
+	}
+	return rgSearcher{"rg"}, true
+}
+
+// args are the flags every rg run gets: literal unless the match mode is
+// regex, and under the same depth and ignore rules as the other walkers
+func (rgSearcher) args(opts searchOpts) []string {
+	args := []string{"--no-messages", "--color", "never"}
+	if !opts.regex {
+		args = append(args, "--fixed-strings")
+	}
+	if opts.ignoreCase {
+		args = append(args, "--ignore-case")
+	} else {
+		args = append(args, "--case-sensitive")
+	}
+	if opts.maxDepth > 0 {
+		args = append(args, "--max-depth", strconv.Itoa(opts.maxDepth))
+	}
+	for _, glob := range opts.ignore {
+		args = append(args, "--glob", "!"+glob)
+	}
+	return args
+}
+
+// Search lists the files with at least one match, as rg finds them. The
+// terms are one phrase, as typed.
+func (r rgSearcher) Search(ctx context.Context, patterns []string, opts searchOpts) (<-chan result, error) {
+	args := append([]string{"--files-with-matches", "--null"}, r.args(opts)...)
+	args = append(args, "-e", strings.Join(patterns, " "), "--", opts.root)
+	return streamNull(ctx, exec.CommandContext(ctx, r.command, args...))
+}
+
+// firstMatch is the first line of path matching any of alts, with its number
+func (r rgSearcher) firstMatch(path string, alts [][]string, opts searchOpts) string {
+	args := append([]string{"--max-count", "1", "--line-number", "--no-filename"}, r.args(opts)...)
+	for _, patterns := range alts {
+		args = append(args, "-e", strings.Join(patterns, " "))
+	}
+	out, _ := exec.Command(r.command, append(args, "--", path)...).Output()
+	return cleanCell(strings.TrimSpace(firstLine(string(out))))
+}
+
+// maxContainsSize is the largest file a content: clause reads, bigger ones never match
+const maxContainsSize = 32 << 20
+
+// containsFilter keeps the regular files containing every one of terms, for
+// content: clauses narrowing down what the name patterns found
+func containsFilter(terms []string, ignoreCase bool) filter {
+	want := make([][]byte, len(terms))
+	for i, t := range terms {
+		if ignoreCase {
+			t = strings.ToLower(t)
+		}
+		want[i] = []byte(t)
+	}
+	return filter{needsStat: true, keep: func(path string, info os.FileInfo) bool {
+		if !info.Mode().IsRegular() || info.Size() > maxContainsSize {
+			return false
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		if ignoreCase {
+			data = bytes.ToLower(data)
+		}
+		return !slices.ContainsFunc(want, func(w []byte) bool { return !bytes.Contains(data, w) })
+	}}
+}
+
+// matchLine is the first matching line of the selected file in content mode
+type matchLine struct {
+	id   rowID
+	line string // "12:the text", empty while rg is still looking
+}
+
+type matchLineMsg matchLine
+
+// lookupMatchLine finds the first matching line once the cursor lands on a
+// file it hasn't been found for, for the status bar
+func (m *model) lookupMatchLine() tea.Cmd {
+	row := m.selectedRow()
+	rg, ok := contentBackend.(rgSearcher)
+	if !m.content || !ok || row == nil || idOf(row) == m.matchLine.id {
+		return nil
+	}
+	id := idOf(row)
+	m.matchLine = matchLine{id: id}
+	q, err := m.prepareQuery(m.shownQuery)
+	if err != nil || len(q.alts) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		return matchLineMsg{id, rg.firstMatch(string(id), q.alts, q.opts)}
+	}
+}
+
+// matchLineStatus shows the selected file's first match, clipped to one line of the status bar
+func (m model) matchLineStatus() string {
+	row := m.selectedRow()
+	if !m.content || row == nil || idOf(row) != m.matchLine.id || m.matchLine.line == "" {
+		return ""
+	}
+	n, text, _ := strings.Cut(m.matchLine.line, ":")
+	return truncateRight("line "+n+": "+strings.TrimSpace(text), max(m.width/2, 20))
+}
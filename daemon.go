@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// gocate daemon keeps the indexes gocate index built in memory, following
+// changes under them too with watch_index set, and answers searches over a
+// unix socket. The TUI searches through it whenever it's running instead of
+// decompressing the index files again for every query.
+//
+// A request is a daemonRequest as one line of JSON. The answer starts with a
+// line, "ok" or "error: " and why, then each matching path, ended by a NUL
+// like locate -0 prints them.
+
+// daemonSocket is where the daemon listens, only this user may connect
+func daemonSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "gocate.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gocate-%d.sock", os.Getuid()))
+}
+
+// daemonRequest is a search sent to the daemon, with the searchOpts an index
+// matches on. Like locate, root and ignore are left to the query's clauses.
+type daemonRequest struct {
+	Patterns   []string `json:"patterns"`
+	Regex      bool     `json:"regex,omitempty"`
+	BasicRegex bool     `json:"basic_regex,omitempty"`
+	IgnoreCase bool     `json:"ignore_case,omitempty"`
+	Basename   bool     `json:"basename,omitempty"`
+}
+
+func newDaemonRequest(patterns []string, opts searchOpts) daemonRequest {
+	return daemonRequest{Patterns: patterns, Regex: opts.regex, BasicRegex: opts.basicRegex,
+		IgnoreCase: opts.ignoreCase, Basename: opts.basename}
+}
+
+func (r daemonRequest) opts() searchOpts {
+	return searchOpts{regex: r.Regex, basicRegex: r.BasicRegex, ignoreCase: r.IgnoreCase, basename: r.Basename}
+}
+
+// memoryIndex is every path in the indexes, loaded again whenever gocate index
+// rewrites one of them
+type memoryIndex struct {
+	mu    sync.RWMutex
+	paths []string
+	files map[string]int64 // index file -> its mtime when loaded, in Unix nanoseconds
+}
+
+// current is the paths in the indexes, reloaded first if any index file was
+// rebuilt, added or removed since. Checking only takes a stat per file. The
+// watcher's changes from before a reload are in the new files, so the overlay
+// forgets them once it's done, as reindexAll does in the process that built them.
+func (mi *memoryIndex) current() ([]string, error) {
+	files := map[string]int64{}
+	for _, name := range indexFiles() {
+		if info, err := os.Stat(name); err == nil {
+			files[name] = info.ModTime().UnixNano()
+		}
+	}
+	mi.mu.RLock()
+	paths, same := mi.paths, mi.files != nil && maps.Equal(files, mi.files)
+	mi.mu.RUnlock()
+	if same {
+		return paths, nil
+	}
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+	if mi.files != nil && maps.Equal(files, mi.files) { // another request reloaded them meanwhile
+		return mi.paths, nil
+	}
+	seq := 0
+	if o := overlay.Load(); o != nil {
+		seq = o.seq
+	}
+	roots := indexRoots()
+	if len(roots) == 0 {
+		return nil, errors.New("no index yet, run gocate index with the directories to search")
+	}
+	paths = nil
+	for _, root := range slices.Sorted(maps.Keys(roots)) {
+		ir, err := openIndex(roots[root])
+		if err != nil {
+			return nil, err
+		}
+		for {
+			e, err := ir.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				ir.Close()
+				return nil, fmt.Errorf("%s: %w", roots[root], err)
+			}
+			paths = append(paths, e.path)
+		}
+		ir.Close()
+	}
+	mi.paths, mi.files = paths, files
+	forgetChanges(seq)
+	return paths, nil
+}
+
+// serve answers one request. A client that stops reading, e.g. because its
+// search was superseded, ends the answer early.
+func (mi *memoryIndex) serve(conn net.Conn) {
+	defer conn.Close()
+	w := bufio.NewWriter(conn)
+	defer w.Flush()
+	var req daemonRequest
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &req)
+	}
+	var match func(string) bool
+	if err == nil {
+		match, err = pathMatcher(req.Patterns, req.opts())
+	}
+	var paths []string
+	if err == nil {
+		paths, err = mi.current()
+	}
+	if err != nil {
+		fmt.Fprintf(w, "error: %s\n", firstLine(err.Error()))
+		return
+	}
+	ov := overlay.Load()
+	found := func(yield func(string) bool) {
+		for _, p := range paths {
+			if match(p) && !ov.hides(p) && !yield(p) {
+				return
+			}
+		}
+		for _, p := range ov.created() {
+			if match(p) && !yield(p) {
+				return
+			}
+		}
+	}
+	fmt.Fprintln(w, "ok")
+	for p := range found {
+		w.WriteString(p)
+		if err := w.WriteByte(0); err != nil {
+			return
+		}
+	}
+}
+
+// runDaemon is gocate daemon: it loads the indexes and serves searches on
+// daemonSocket until interrupted
+func runDaemon(out io.Writer) error {
+	cfg, _ := loadConfig() // watch_index and the ignore list, the defaults do without
+	socket := daemonSocket()
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		return fmt.Errorf("already running on %s", socket)
+	}
+	os.Remove(socket) // left behind by one that didn't stop cleanly
+	mi := &memoryIndex{}
+	paths, err := mi.current()
+	if err != nil {
+		return err
+	}
+	umask := syscall.Umask(0o177)
+	ln, err := net.Listen("unix", socket)
+	syscall.Umask(umask)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, func() { ln.Close() }) // which removes the socket
+	if cfg.WatchIndex {
+		go func() {
+			for ch := watchIndex(cfg.Ignore); ; {
+				msg := <-ch
+				if msg.err != nil {
+					fmt.Fprintln(os.Stderr, "watching the index:", msg.err)
+				}
+			}
+		}()
+	}
+	fmt.Fprintf(out, "Serving %d paths on %s\n", len(paths), socket)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go mi.serve(conn)
+	}
+}
+
+// daemonSearcher searches through a running gocate daemon, or reads the index
+// files itself if the daemon has gone since
+type daemonSearcher struct {
+	socket string
+}
+
+// detectDaemon finds a daemon answering on daemonSocket
+func detectDaemon() (daemonSearcher, bool) {
+	socket := daemonSocket()
+	conn, err := net.DialTimeout("unix", socket, 200*time.Millisecond)
+	if err != nil {
+		return daemonSearcher{}, false
+	}
+	conn.Close()
+	return daemonSearcher{socket}, true
+}
+
+// ask sends a request and reads the status line, returning the connection to
+// read the rest of the answer from
+func (d daemonSearcher) ask(ctx context.Context, req daemonRequest) (net.Conn, *bufio.Reader, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", d.socket)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := bufio.NewReader(conn)
+	status, err := "", json.NewEncoder(conn).Encode(req)
+	if err == nil {
+		status, err = r.ReadString('\n')
+	}
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("gocate daemon: %w", err)
+	}
+	status = strings.TrimSuffix(status, "\n")
+	if msg, ok := strings.CutPrefix(status, "error: "); ok {
+		conn.Close()
+		return nil, nil, errors.New(msg)
+	}
+	return conn, r, nil
+}
+
+func (d daemonSearcher) Search(ctx context.Context, patterns []string, opts searchOpts) (<-chan result, error) {
+	conn, r, err := d.ask(ctx, newDaemonRequest(patterns, opts))
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" { // stopped since gocate started
+		return indexSearcher{}.Search(ctx, patterns, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	results := make(chan result)
+	go func() {
+		defer close(results)
+		defer conn.Close()
+		stop := context.AfterFunc(ctx, func() { conn.Close() }) // tells the daemon to stop too
+		defer stop()
+		sc := bufio.NewScanner(r)
+		sc.Split(scanNull)
+		for sc.Scan() {
+			select {
+			case results <- result{path: sc.Text()}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := sc.Err(); err != nil && ctx.Err() == nil {
+			select {
+			case results <- result{err: fmt.Errorf("gocate daemon: %w", err)}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return results, nil
+}
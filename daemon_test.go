@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMemoryIndexReload(t *testing.T) {
+	root := indexTree(t)
+	t.Cleanup(func() { overlay.Store(nil) })
+	if _, err := buildIndex(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+	mi := &memoryIndex{}
+	if _, err := mi.current(); err != nil {
+		t.Fatal(err)
+	}
+	added := filepath.Join(root, "docs", "added.txt")
+	if err := os.WriteFile(added, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	applyChanges([]indexChange{{path: added}})
+
+	// Unchanged index files keep the overlay, it's all that knows of the file
+	if _, err := mi.current(); err != nil {
+		t.Fatal(err)
+	}
+	if overlay.Load() == nil {
+		t.Fatal("overlay dropped though the index files didn't change")
+	}
+
+	// Rebuilt elsewhere, e.g. by gocate index, they hold the change themselves
+	if _, err := buildIndex(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+	name, _ := indexFile(root)
+	later := time.Now().Add(time.Second) // whatever the file system's mtime resolution
+	if err := os.Chtimes(name, later, later); err != nil {
+		t.Fatal(err)
+	}
+	paths, err := mi.current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(paths, added) {
+		t.Errorf("reloaded index is missing %s", added)
+	}
+	if o := overlay.Load(); o != nil {
+		t.Errorf("overlay after reloading = %+v, want none", o)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// databasesSearcher runs one locate per database in the config's databases,
+// e.g. the system one and a per-user one, merging them into one stream. A
+// path in several counts once, as found in the first one listed.
+type databasesSearcher struct {
+	each   []locateSearcher // one per database, with its -d set
+	labels []string         // each database as the config names it, for the database column
+}
+
+// withDatabases searches l's command over dbs instead of its default database.
+// Databases on this machine may start with ~.
+func withDatabases(l locateSearcher, dbs []string, home string) databasesSearcher {
+	var s databasesSearcher
+	for _, db := range dbs {
+		one := l
+		one.database = db
+		if l.host == "" {
+			one.database = expandHome(db, home)
+		}
+		one.pick = true
+		s.each = append(s.each, one)
+		s.labels = append(s.labels, db)
+	}
+	return s
+}
+
+// Search streams each database's matches in turn, each path tagged with the
+// database it came from. A failing database ends the search like any failed
+// locate would.
+func (s databasesSearcher) Search(ctx context.Context, patterns []string, opts searchOpts) (<-chan result, error) {
+	out := make(chan result)
+	go func() {
+		defer close(out)
+		seen := map[string]bool{}
+		for i, l := range s.each {
+			results, err := l.Search(ctx, patterns, opts)
+			if err != nil {
+				select {
+				case out <- result{err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			for r := range results {
+				if r.err == nil {
+					if seen[r.path] {
+						continue
+					}
+					seen[r.path] = true
+					r.source = s.labels[i]
+				}
+				select {
+				case out <- r:
+				case <-ctx.Done():
+					return
+				}
+				if r.err != nil {
+					return
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// withSource adds the database a result came from to its row, as the last
+// cell, when there are several
+func withSource(row table.Row, source string) table.Row {
+	if source == "" {
+		return row
+	}
+	return append(row, source)
+}
+
+// sourceOf is the database a row's result came from, "" with only the one
+func sourceOf(row table.Row) string {
+	if len(row) > 5 {
+		return row[5]
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// deviceTotal sums the loaded results living on one device
+type deviceTotal struct {
+	name  string
+	count int
+	size  int64
+}
+
+// deviceOf is the device a file lives on, for stats that carry one
+func deviceOf(info os.FileInfo) (uint64, bool) {
+	if info == nil {
+		return 0, false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
+
+// mountPoints maps device numbers to where they're mounted, read once from
+// mountinfo. The first mount wins, which for bind mounts is the original.
+var mountPoints = sync.OnceValue(func() map[uint64]string {
+	mounts := map[uint64]string{}
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return mounts
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		major, minor, ok := strings.Cut(fields[2], ":")
+		maj, err1 := strconv.ParseUint(major, 10, 32)
+		min, err2 := strconv.ParseUint(minor, 10, 32)
+		if !ok || err1 != nil || err2 != nil {
+			continue
+		}
+		dev := unix.Mkdev(uint32(maj), uint32(min))
+		if _, seen := mounts[dev]; !seen {
+			mounts[dev] = unescapeMount(fields[4])
+		}
+	}
+	return mounts
+})
+
+// unescapeMount undoes the \040-style octal escapes mountinfo uses for spaces and the like
+func unescapeMount(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// deviceName labels a device by its mount point, or by major:minor if it isn't mounted here
+func deviceName(dev uint64) string {
+	if mp, ok := mountPoints()[dev]; ok {
+		return mp
+	}
+	return fmt.Sprintf("%d:%d", unix.Major(dev), unix.Minor(dev))
+}
+
+// deviceTotals adds up the statted results per device, biggest first
+func (m model) deviceTotals() []deviceTotal {
+	byDev := map[uint64]*deviceTotal{}
+	for _, row := range m.results {
+		info := m.infos[idOf(row)]
+		dev, ok := deviceOf(info)
+		if !ok {
+			continue
+		}
+		t := byDev[dev]
+		if t == nil {
+			t = &deviceTotal{name: deviceName(dev)}
+			byDev[dev] = t
+		}
+		t.count++
+		if !info.IsDir() {
+			t.size += info.Size()
+		}
+	}
+	totals := make([]deviceTotal, 0, len(byDev))
+	for _, t := range byDev {
+		totals = append(totals, *t)
+	}
+	slices.SortFunc(totals, func(a, b deviceTotal) int {
+		return cmp.Or(cmp.Compare(b.size, a.size), strings.Compare(a.name, b.name))
+	})
+	return totals
+}
+
+// deviceSummary is the status line suffix while sorting by device
+func (m model) deviceSummary() string {
+	var parts []string
+	for _, t := range m.deviceTotals() {
+		parts = append(parts, fmt.Sprintf("%s: %d files, %s", t.name, t.count, formatSize(t.size, m.siUnit)))
+	}
+	return strings.Join(parts, " · ")
+}
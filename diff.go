@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	diffContext  = 3        // unchanged lines around each hunk, as in diff -u
+	maxDiffCells = 4 << 20  // line pairs the LCS table may hold before giving up on a text diff
+	binarySniff  = 8000     // bytes looked at to tell text from binary, like git
+	maxDiffBytes = 16 << 20 // bigger files only get the size and hash comparison
+)
+
+var (
+	diffAddStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffDelStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	diffHunkStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+)
+
+// diffMsg carries a finished comparison of two marked files
+type diffMsg struct {
+	a, b string
+	text string
+	err  error
+}
+
+// compareFiles diffs two text files line by line, and falls back to comparing
+// size and SHA-256 when either one is binary or too big to diff
+func compareFiles(ctx context.Context, a, b string) tea.Msg {
+	da, erra := readForDiff(a)
+	db, errb := readForDiff(b)
+	if err := errors.Join(erra, errb); err != nil {
+		return diffMsg{a: a, b: b, err: err}
+	}
+	if da != nil && db != nil && isText(da) && isText(db) {
+		al, bl := splitLines(string(da)), splitLines(string(db))
+		if text, ok := unifiedDiff(a, b, al, bl); ok {
+			return diffMsg{a: a, b: b, text: text}
+		}
+	}
+	return diffMsg{a: a, b: b, text: binaryComparison(ctx, a, b)}
+}
+
+// readForDiff loads a file small enough to diff, or returns nil data for a bigger one
+func readForDiff(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxDiffBytes {
+		return nil, nil
+	}
+	return os.ReadFile(path)
+}
+
+func isText(data []byte) bool {
+	head := data[:min(len(data), binarySniff)]
+	return bytes.IndexByte(head, 0) < 0 && utf8.Valid(head[:len(head)-incompleteRune(head)])
+}
+
+// incompleteRune counts the bytes of a rune cut off at the end of b
+func incompleteRune(b []byte) int {
+	for i := 1; i <= min(len(b), utf8.UTFMax-1); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return 0
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" { // nothing after the final newline
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func binaryComparison(ctx context.Context, a, b string) string {
+	var sb strings.Builder
+	sums := hashFiles(ctx, []string{a, b})
+	same := sums[0].err == nil && sums[1].err == nil && sums[0].sum == sums[1].sum
+	for _, s := range sums {
+		size := "?"
+		if info, err := os.Stat(s.path); err == nil {
+			size = formatSize(info.Size(), false)
+		}
+		if s.err != nil {
+			fmt.Fprintf(&sb, "%s\n  %s, %v\n", s.path, size, s.err)
+		} else {
+			fmt.Fprintf(&sb, "%s\n  %s, sha256 %s\n", s.path, size, s.sum)
+		}
+	}
+	if same {
+		return sb.String() + "\nBinary files are identical"
+	}
+	return sb.String() + "\nBinary files differ"
+}
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff renders the changes from a to b in diff -u format, or reports
+// false if the files are too far apart to diff in memory
+func unifiedDiff(aName, bName string, a, b []string) (string, bool) {
+	ops, ok := diffLines(a, b)
+	if !ok {
+		return "", false
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+	changed := false
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		changed = true
+		// grow the hunk while the next change is within two contexts' reach
+		start, end := max(i-diffContext, 0), i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end = min(end+diffContext, len(ops))
+		writeHunk(&sb, ops, start, end)
+		i = end
+	}
+	if !changed {
+		return "Files are identical", true
+	}
+	return sb.String(), true
+}
+
+func writeHunk(sb *strings.Builder, ops []diffOp, start, end int) {
+	aLine, bLine := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			aLine++
+		}
+		if op.kind != '-' {
+			bLine++
+		}
+	}
+	var aCount, bCount int
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			aCount++
+		}
+		if op.kind != '-' {
+			bCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+	for _, op := range ops[start:end] {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// diffLines finds a shortest edit script through the longest common
+// subsequence, after stripping the prefix and suffix both sides share
+func diffLines(a, b []string) ([]diffOp, bool) {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if (len(ma)+1)*(len(mb)+1) > maxDiffCells {
+		return nil, false
+	}
+
+	// lcs[i][j] is the LCS length of ma[i:] and mb[j:]
+	w := len(mb) + 1
+	lcs := make([]int32, (len(ma)+1)*w)
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else {
+				lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, l := range a[:pre] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{' ', ma[i]})
+			i, j = i+1, j+1
+		case j == len(mb) || (i < len(ma) && lcs[(i+1)*w+j] >= lcs[i*w+j+1]):
+			ops = append(ops, diffOp{'-', ma[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', mb[j]})
+			j++
+		}
+	}
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops, true
+}
+
+// colorDiff highlights added, removed and hunk header lines
+func colorDiff(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		switch {
+		case i < 2: // the --- and +++ file names
+			lines[i] = dialogTitleStyle.Render(l)
+		case strings.HasPrefix(l, "@@"):
+			lines[i] = diffHunkStyle.Render(l)
+		case strings.HasPrefix(l, "+"):
+			lines[i] = diffAddStyle.Render(l)
+		case strings.HasPrefix(l, "-"):
+			lines[i] = diffDelStyle.Render(l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var pagerScroll = key.NewBinding(key.WithKeys("up", "down"), key.WithHelp("↑/↓/pgup/pgdn", "scroll"))
+
+// pagerDialog is a scrollable read-only dialog for text longer than the screen
+type pagerDialog struct {
+	title    string
+	viewport viewport.Model
+}
+
+func newPagerDialog(title, body string, width, height int) pagerDialog {
+	vp := viewport.New(max(width-8, 20), max(height-12, 5))
+	vp.SetContent(strings.ReplaceAll(body, "\t", "    "))
+	return pagerDialog{title: title, viewport: vp}
+}
+
+func (d pagerDialog) Update(msg tea.Msg) (dialog, tea.Cmd) {
+	var cmd tea.Cmd
+	d.viewport, cmd = d.viewport.Update(msg)
+	return d, cmd
+}
+
+func (d pagerDialog) View() string {
+	pos := fmt.Sprintf(" %3.f%%", d.viewport.ScrollPercent()*100)
+	return dialogTitleStyle.Render(d.title) + settingsDimStyle.Render(pos) + "\n\n" + d.viewport.View() + "\n\n" +
+		help.New().ShortHelpView([]key.Binding{pagerScroll, closeDialog})
+}
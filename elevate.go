@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// elevation tracks results this user isn't allowed to stat, and those read
+// anyway through pkexec, which is only ever run when asked to
+type elevation struct {
+	denied  map[rowID]bool // stat failed with permission denied
+	asRoot  map[rowID]bool // statted through pkexec
+	offered bool           // the retry has been suggested this session
+}
+
+// statFormat is what GNU stat prints for remoteStat and the pkexec helper to read back
+const statFormat = "%s %Y %f"
+
+// parseStat reads a statFormat line back into an os.FileInfo for path
+func parseStat(path, line string) (os.FileInfo, error) {
+	var size, mtime int64
+	var raw uint32
+	if _, err := fmt.Sscanf(line, "%d %d %x", &size, &mtime, &raw); err != nil {
+		return nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+	return remoteInfo{filepath.Base(path), size, unixMode(raw), time.Unix(mtime, 0)}, nil
+}
+
+// deny keeps a result whose stat was refused instead of dropping it as gone,
+// shown locked, and suggests retrying with pkexec the first time it happens
+func (m *model) deny(id rowID) tea.Cmd {
+	if m.elevation.denied == nil {
+		m.elevation.denied = map[rowID]bool{}
+	}
+	m.elevation.denied[id] = true
+	m.updateRow(id, func(row table.Row) { row[0] = "🔒" })
+	if m.elevation.offered || !m.caps.pkexec || m.remote != "" {
+		return nil
+	}
+	m.elevation.offered = true
+	return notify(toastInfo, "Some results can't be read, "+m.keys.Elevate.Help().Key+" reads them with pkexec")
+}
+
+// deniedPaths are the loaded results a stat was refused for
+func (m model) deniedPaths() []string {
+	var paths []string
+	for _, row := range m.results {
+		if m.elevation.denied[idOf(row)] {
+			paths = append(paths, row[2])
+		}
+	}
+	return paths
+}
+
+// elevatedStatMsg carries what the pkexec helper read
+type elevatedStatMsg struct {
+	infos map[rowID]os.FileInfo
+	err   error
+}
+
+// elevate stats every refused result in one pkexec run of stat, handing it
+// the terminal in case polkit has no graphical agent to ask for the password
+func (m model) elevate() tea.Cmd {
+	paths := m.deniedPaths()
+	if len(paths) == 0 {
+		return notify(toastInfo, "Every loaded result could be read already")
+	}
+	if !m.caps.pkexec {
+		return notify(toastWarn, "Install polkit's pkexec to read restricted results")
+	}
+	stat, err := exec.LookPath("stat")
+	if err != nil {
+		return notify(toastWarn, "Install coreutils' stat to read restricted results")
+	}
+	var out bytes.Buffer
+	c := exec.Command("pkexec", append([]string{stat, "-L", "--printf", statFormat + " %n\\0", "--"}, paths...)...)
+	c.Stdout = &out
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		infos := map[rowID]os.FileInfo{}
+		for _, rec := range strings.Split(out.String(), "\x00") {
+			fields := strings.SplitN(rec, " ", 4)
+			if len(fields) < 4 {
+				continue
+			}
+			if info, err := parseStat(fields[3], strings.Join(fields[:3], " ")); err == nil {
+				infos[rowID(fields[3])] = info
+			}
+		}
+		if len(infos) > 0 {
+			err = nil // stat fails as a whole when one path does
+		}
+		return elevatedStatMsg{infos, err}
+	})
+}
+
+// applyElevated fills in the rows pkexec could read
+func (m *model) applyElevated(msg elevatedStatMsg) tea.Cmd {
+	if msg.err != nil {
+		return notify(toastError, "pkexec: "+msg.err.Error())
+	}
+	if m.elevation.asRoot == nil {
+		m.elevation.asRoot = map[rowID]bool{}
+	}
+	for id, info := range msg.infos {
+		delete(m.elevation.denied, id)
+		m.elevation.asRoot[id] = true
+		if m.infos != nil {
+			m.infos[id] = info
+		}
+		row := buildRow(string(id), info, m.siUnit, m.types)
+		m.updateRow(id, func(r table.Row) { copy(r, row) })
+	}
+	return notify(toastInfo, fmt.Sprintf("Read %d restricted results as root", len(msg.infos)))
+}
+
+// elevationStatus counts the loaded results that couldn't be read, those this
+// user may read but not change, and those read as root, "" when there are none
+func (m model) elevationStatus() string {
+	denied, readOnly, asRoot := 0, 0, 0
+	for _, row := range m.results {
+		a, ok := accessOf(m.infos[idOf(row)])
+		switch {
+		case m.elevation.denied[idOf(row)] || ok && !a.read:
+			denied++
+		case ok && !a.write:
+			readOnly++
+		}
+		if m.elevation.asRoot[idOf(row)] {
+			asRoot++
+		}
+	}
+	var parts []string
+	if denied > 0 {
+		parts = append(parts, fmt.Sprintf("🔒 %d unreadable", denied))
+	}
+	if readOnly > 0 {
+		parts = append(parts, fmt.Sprintf("🔏 %d read-only", readOnly))
+	}
+	if asRoot > 0 {
+		parts = append(parts, fmt.Sprintf("%d read as root", asRoot))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// fixture is the canned file list -fake-backend serves instead of plocate and
+// the filesystem, for driving the TUI deterministically in tests and recordings:
+//
+//	{"files": [{"path": "/home/me/notes.md", "size": 1200, "mode": "644", "mod_time": "2024-05-01T10:00:00Z"},
+//	           {"path": "/home/me/src", "dir": true}]}
+type fixture struct {
+	Files []fixtureFile `json:"files"`
+}
+
+type fixtureFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size,omitempty"`
+	Mode    string    `json:"mode,omitempty"` // octal permissions, 644 or 755 when left out
+	Dir     bool      `json:"dir,omitempty"`
+	ModTime time.Time `json:"mod_time,omitempty"`
+}
+
+func loadFixture(name string) (fixture, error) {
+	var fx fixture
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fx, err
+	}
+	if err := json.Unmarshal(data, &fx); err != nil {
+		return fx, fmt.Errorf("%s: %w", name, err)
+	}
+	for _, f := range fx.Files {
+		if _, err := f.mode(); err != nil {
+			return fx, fmt.Errorf("%s: %s: %w", name, f.Path, err)
+		}
+	}
+	return fx, nil
+}
+
+func (f fixtureFile) mode() (fs.FileMode, error) {
+	mode := fs.FileMode(0o644)
+	if f.Dir {
+		mode = fs.ModeDir | 0o755
+	}
+	if f.Mode != "" {
+		perm, err := strconv.ParseUint(f.Mode, 8, 32)
+		if err != nil {
+			return 0, fmt.Errorf("mode %q isn't octal", f.Mode)
+		}
+		mode = mode&fs.ModeType | fs.FileMode(perm)&fs.ModePerm
+	}
+	return mode, nil
+}
+
+// useFixture points searches and stats at fx for the rest of the run
+func useFixture(fx fixture) {
+	infos := map[string]os.FileInfo{}
+	for _, f := range fx.Files {
+		mode, _ := f.mode() // checked by loadFixture
+		infos[f.Path] = fixtureInfo{f, mode}
+	}
+	backend = fixtureSearcher{fx}
+	liveBackend = backend
+	statPath = func(path string) (os.FileInfo, error) {
+		if info, ok := infos[path]; ok {
+			return info, nil
+		}
+		return nil, &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+	}
+}
+
+// fixtureSearcher searches a fixture's paths in the order they're listed
+type fixtureSearcher struct {
+	fx fixture
+}
+
+func (s fixtureSearcher) Search(ctx context.Context, patterns []string, opts searchOpts) (<-chan result, error) {
+	match, err := pathMatcher(patterns, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := make(chan result)
+	go func() {
+		defer close(results)
+		for _, f := range s.fx.Files {
+			if !match(f.Path) {
+				continue
+			}
+			select {
+			case results <- result{path: f.Path}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results, nil
+}
+
+// fixtureInfo is the os.FileInfo of a fixture entry
+type fixtureInfo struct {
+	f    fixtureFile
+	mode fs.FileMode
+}
+
+func (i fixtureInfo) Name() string       { return filepath.Base(i.f.Path) }
+func (i fixtureInfo) Size() int64        { return i.f.Size }
+func (i fixtureInfo) Mode() fs.FileMode  { return i.mode }
+func (i fixtureInfo) ModTime() time.Time { return i.f.ModTime }
+func (i fixtureInfo) IsDir() bool        { return i.f.Dir }
+func (i fixtureInfo) Sys() any           { return nil }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+)
+
+// fdSearcher walks the filesystem with fd on every search, for when the locate
+// database is stale or never covered the directory. It's slower, so it's best
+// with a root set.
+type fdSearcher struct {
+	command string // fd, or fdfind as Debian and Ubuntu name it
+}
+
+// detectFd finds fd under either of its names
+func detectFd() (fdSearcher, bool) {
+	for _, command := range []string{"fd", "fdfind"} {
+		if _, err := exec.LookPath(command); err == nil {
+			return fdSearcher{command}, true
+		}
+	}
+	return fdSearcher{}, false
+}
+
+// args mirrors locate's matching: every pattern has to match the whole path,
+// or the name with basename. fd ignores nothing and hides nothing, like the
+// database, and only takes one pattern so the rest are added with --and.
+func (fdSearcher) args(patterns []string, opts searchOpts) []string {
+	args := []string{"-0", "--absolute-path", "--hidden", "--no-ignore", "--color", "never"}
+	if !opts.regex {
+		args = append(args, "--fixed-strings")
+	}
+	if opts.ignoreCase {
+		args = append(args, "--ignore-case")
+	} else {
+		args = append(args, "--case-sensitive")
+	}
+	if !opts.basename {
+		args = append(args, "--full-path")
+	}
+	if opts.maxDepth > 0 {
+		args = append(args, "--max-depth", strconv.Itoa(opts.maxDepth))
+	}
+	for _, glob := range opts.ignore {
+		args = append(args, "--exclude", glob)
+	}
+	for _, p := range patterns[1:] {
+		args = append(args, "--and", p)
+	}
+	return append(args, "--", patterns[0], cmp.Or(opts.root, "/"))
+}
+
+// Search streams what fd finds under the root. Directories fd can't read
+// aren't errors, it skips them quietly the way updatedb does.
+func (f fdSearcher) Search(ctx context.Context, patterns []string, opts searchOpts) (<-chan result, error) {
+	if opts.basicRegex {
+		return nil, errors.New("fd has no basic regexes, switch to another match mode for live search")
+	}
+	return streamNull(ctx, exec.CommandContext(ctx, f.command, f.args(patterns, opts)...))
+}
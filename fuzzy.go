@@ -0,0 +1,115 @@
+package main
+
+import (
+	"cmp"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// fuzzyRegex is what plocate is given for a fuzzy term: its letters in order
+// with anything in between, so plocate finds every candidate and gocate ranks them
+func fuzzyRegex(term string) string {
+	var parts []string
+	for _, r := range term {
+		parts = append(parts, regexp.QuoteMeta(string(r)))
+	}
+	return strings.Join(parts, ".*")
+}
+
+const (
+	fuzzyMatch       = 16 // every letter of the term found
+	fuzzyConsecutive = 8  // right after the previous letter
+	fuzzyBoundary    = 8  // at the start of a path component or word
+	fuzzyBasename    = 32 // the whole term matched within the file name
+)
+
+// fuzzyScore rates how well term matches path the way fzf does, or reports that
+// it doesn't match: runs of consecutive letters and letters starting a word
+// count most, gaps count against it, and a match inside the file name beats
+// one spread over the directories. Both are expected case-folded already.
+func fuzzyScore(path, term string) (int, bool) {
+	p, t := []rune(path), []rune(term)
+	if len(t) == 0 {
+		return 0, true
+	}
+	base := strings.LastIndexByte(path, '/') + 1
+	base = len([]rune(path[:base]))
+	best, found := 0, false
+	for start := range p {
+		if p[start] != t[0] {
+			continue
+		}
+		score, j, prev := 0, 0, -1
+		for i := start; i < len(p) && j < len(t); i++ {
+			if p[i] != t[j] {
+				continue
+			}
+			score += fuzzyMatch
+			if prev >= 0 {
+				if i == prev+1 {
+					score += fuzzyConsecutive
+				} else {
+					score -= min(i-prev-1, fuzzyMatch/2) // long gaps shouldn't swamp the rest
+				}
+			}
+			if i == 0 || !unicode.IsLetter(p[i-1]) && !unicode.IsDigit(p[i-1]) {
+				score += fuzzyBoundary
+			}
+			prev = i
+			j++
+		}
+		if j < len(t) {
+			break // later starts can't find the rest either
+		}
+		if start >= base {
+			score += fuzzyBasename
+		}
+		if !found || score > best {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+// fuzzyScorer rates a path against the query's alternatives, taking the best
+// one it fully matches, for runSearch to keep the top results by
+func (m model) fuzzyScorer(alts [][]string) func(path string) int {
+	return func(path string) int {
+		subject := m.subject(path)
+		best := 0
+		for _, terms := range alts {
+			total, ok := 0, true
+			for _, t := range terms {
+				s, matched := fuzzyScore(subject, strings.ToLower(t))
+				total += s
+				ok = ok && matched
+			}
+			if ok {
+				best = max(best, total)
+			}
+		}
+		return best
+	}
+}
+
+// scoredPath is a search result waiting to be ranked
+type scoredPath struct {
+	path   string
+	source string
+	info   os.FileInfo // when a clause already statted it
+	score  int
+}
+
+// byScore orders the best matches first, and the shorter path of two equal ones
+func byScore(a, b scoredPath) int {
+	return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(len(a.path), len(b.path)), strings.Compare(a.path, b.path))
+}
+
+// topScored keeps the first n of candidates in the order of compare, such as byScore
+func topScored(candidates []scoredPath, n int, compare func(a, b scoredPath) int) []scoredPath {
+	slices.SortFunc(candidates, compare)
+	return candidates[:min(n, len(candidates))]
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		path, term string
+		score      int
+		ok         bool
+	}{
+		{"abc", "", 0, true},
+		{"abc", "abc", 104, true},    // 3 matches, 2 consecutive, a boundary and the file name
+		{"/x/abc", "abc", 104, true}, // the slash before counts as a boundary as well
+		{"/x/a-b", "ab", 79, true},   // two boundaries and a gap of one, no run
+		{"/abc/x", "abc", 72, true},  // no file name bonus
+		{"abc", "abd", 0, false},
+		{"abc", "cba", 0, false}, // letters out of order
+		{"ab", "abc", 0, false},
+	}
+	for _, tt := range tests {
+		score, ok := fuzzyScore(tt.path, tt.term)
+		if score != tt.score || ok != tt.ok {
+			t.Errorf("fuzzyScore(%q, %q) = %d, %v, want %d, %v", tt.path, tt.term, score, ok, tt.score, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyScoreRanking(t *testing.T) {
+	tests := []struct {
+		term          string
+		better, worse string
+	}{
+		{"main", "/src/main.go", "/src/m/a/i/n.go"},            // consecutive beats spread out
+		{"main", "/src/main.go", "/main/src/x.go"},             // in the file name beats in a directory
+		{"rep", "/docs/report.pdf", "/docs/prepare.pdf"},       // a word start beats the middle
+		{"fb", "/x/foo_bar.go", "/x/fabric.go"},                // both letters start words
+		{"cfg", "/etc/app/config.go", "/etc/app/c/x/f/y/g.go"}, // short gaps beat long ones
+		{"doc", "/home/doc/readme", "/home/d/o/c/readme"},      // even outside the file name
+		{"abc", "/x/abc", "/x/xabc"},                           // a word start beats the same letters inside one
+		{"ab", "/x/ab/ab", "/x/ab/a_long_gap_before_b"},        // the best of every start is kept
+	}
+	for _, tt := range tests {
+		better, ok1 := fuzzyScore(tt.better, tt.term)
+		worse, ok2 := fuzzyScore(tt.worse, tt.term)
+		if !ok1 || !ok2 || better <= worse {
+			t.Errorf("%q: %s scored %d (%v), %s %d (%v), want the first higher", tt.term, tt.better, better, ok1, tt.worse, worse, ok2)
+		}
+	}
+}
+
+func TestFuzzyRegex(t *testing.T) {
+	re := regexp.MustCompilePOSIX(fuzzyRegex("a.c"))
+	for path, want := range map[string]bool{"/x/a.c": true, "/x/aX.Yc": true, "/x/abc": false, "/x/c.a": false} {
+		if got := re.MatchString(path); got != want {
+			t.Errorf("fuzzyRegex(a.c) matching %q = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxHistory is how many earlier commands each prompt remembers
+const maxHistory = 50
+
+// historyPath is the file a prompt's history is kept in, one command per line
+func historyPath(name string) (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+"_history"), nil
+}
+
+// loadHistory reads the remembered commands, newest first
+func loadHistory(name string) []string {
+	path, err := historyPath(name)
+	if err != nil {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var history []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if line := sc.Text(); line != "" {
+			history = append(history, line)
+		}
+	}
+	slices.Reverse(history)
+	return history
+}
+
+// saveHistory remembers command as the newest, dropping an earlier copy of it
+func saveHistory(name, command string) error {
+	path, err := historyPath(name)
+	if err != nil {
+		return err
+	}
+	history := slices.DeleteFunc(loadHistory(name), func(c string) bool { return c == command })
+	history = append([]string{command}, history...)
+	history = history[:min(len(history), maxHistory)]
+	slices.Reverse(history) // oldest first in the file, like a shell's
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(history, "\n")+"\n"), 0o600)
+}
+
+// forgetHistory drops command from the remembered ones
+func forgetHistory(name, command string) error {
+	path, err := historyPath(name)
+	if err != nil {
+		return err
+	}
+	history := slices.DeleteFunc(loadHistory(name), func(c string) bool { return c == command })
+	slices.Reverse(history)
+	if len(history) == 0 {
+		return os.WriteFile(path, nil, 0o600)
+	}
+	return os.WriteFile(path, []byte(strings.Join(history, "\n")+"\n"), 0o600)
+}
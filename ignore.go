@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ignoreFilter drops paths with any component matching one of patterns, which
+// are file name globs: .cache and node_modules leave out everything inside
+// those directories, *.o leaves out object files wherever they are
+func ignoreFilter(patterns []string) filter {
+	return filter{keep: func(path string, _ os.FileInfo) bool {
+		return !slices.ContainsFunc(strings.Split(path, "/"), func(part string) bool { return ignoredName(patterns, part) })
+	}}
+}
+
+// ignoredName is whether one path component matches any of patterns
+func ignoredName(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(p string) bool {
+		ok, _ := filepath.Match(p, name)
+		return ok
+	})
+}
+
+// newIgnoreDialog edits the ignore list as one line of space separated patterns
+func (m model) newIgnoreDialog() promptDialog {
+	cfg := m.cfg
+	return newPromptDialog("Always ignore", "Names or globs like .cache node_modules *.o, separated by spaces", "Ignore: ", strings.Join(cfg.Ignore, " "), func(value string) tea.Msg {
+		cfg.Ignore = strings.Fields(value)
+		for _, p := range cfg.Ignore {
+			if _, err := filepath.Match(p, ""); err != nil {
+				return toastMsg{toastError, "Ignore pattern " + p + ": " + err.Error()}
+			}
+		}
+		return configChangedMsg{cfg}
+	})
+}
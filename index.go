@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// gocate's own index, for machines without a locate or without root to run
+// updatedb: gocate index ~/ walks a directory and saves every path under it,
+// one file per directory indexed, under the XDG data dir. Only the user who
+// built it reads it, so it needs no setuid helper the way plocate does.
+//
+// An index file is gzipped. It starts with indexMagic, the root and the ignore
+// list it was built with, as JSON, on a line each, then holds one record per path, in walk order so neighbours share most
+// of their path: the length of the prefix shared with the path before, the
+// rest of the path, and a flag byte; directories also get their mtime, so
+// indexing again only re-reads the directories that changed.
+
+const indexMagic = "gocate-index 2"
+
+// indexMagicV1 is the format before the ignore list was kept, still searched
+// but built again in full, since what it left out isn't known
+const indexMagicV1 = "gocate-index 1"
+
+const indexDirFlag = 1
+
+// indexEntry is one path in an index
+type indexEntry struct {
+	path  string
+	dir   bool
+	mtime int64 // directories only, in Unix nanoseconds
+}
+
+// dataDir follows the XDG base directory spec for data, which indexes are
+func dataDir() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "gocate"), nil
+}
+
+// indexFile is where the index of root is kept
+func indexFile(root string) (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "index", url.PathEscape(root)+".idx"), nil
+}
+
+// indexFiles lists the index files there are, nil when none have been built
+func indexFiles() []string {
+	dir, err := dataDir()
+	if err != nil {
+		return nil
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "index", "*.idx"))
+	return files
+}
+
+// indexReader reads an index file's records in order
+type indexReader struct {
+	f    *os.File
+	z    *gzip.Reader
+	r    *bufio.Reader
+	root string
+	prev string
+
+	ignore      []string // the names left out of it
+	knownIgnore bool     // false for an index from before they were recorded
+}
+
+func openIndex(name string) (*indexReader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	z, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	ir := &indexReader{f: f, z: z, r: bufio.NewReader(z)}
+	magic, _ := ir.r.ReadString('\n')
+	magic = strings.TrimSuffix(magic, "\n")
+	root, err := ir.r.ReadString('\n')
+	if magic == indexMagic && err == nil {
+		var ignore string
+		if ignore, err = ir.r.ReadString('\n'); err == nil {
+			err = json.Unmarshal([]byte(ignore), &ir.ignore)
+		}
+		ir.knownIgnore = true
+	}
+	if (magic != indexMagic && magic != indexMagicV1) || err != nil {
+		ir.Close()
+		return nil, fmt.Errorf("%s isn't a gocate index, run gocate index again", name)
+	}
+	ir.root = strings.TrimSuffix(root, "\n")
+	return ir, nil
+}
+
+// next reads the next record, io.EOF after the last
+func (ir *indexReader) next() (indexEntry, error) {
+	var e indexEntry
+	shared, err := binary.ReadUvarint(ir.r)
+	if err != nil {
+		return e, err // io.EOF exactly at the end of a record
+	}
+	n, err := binary.ReadUvarint(ir.r)
+	if err != nil || shared > uint64(len(ir.prev)) {
+		return e, errors.Join(errors.New("corrupt index"), err)
+	}
+	rest := make([]byte, n)
+	if _, err := io.ReadFull(ir.r, rest); err != nil {
+		return e, err
+	}
+	flags, err := ir.r.ReadByte()
+	if err != nil {
+		return e, err
+	}
+	e.path = ir.prev[:shared] + string(rest)
+	e.dir = flags&indexDirFlag != 0
+	if e.dir {
+		if e.mtime, err = binary.ReadVarint(ir.r); err != nil {
+			return e, err
+		}
+	}
+	ir.prev = e.path
+	return e, nil
+}
+
+func (ir *indexReader) Close() error {
+	ir.z.Close()
+	return ir.f.Close()
+}
+
+// indexSearcher searches the indexes gocate index built, matching paths in Go
+// the way locate would
+type indexSearcher struct{}
+
+// indexRoots are the roots of the index files there are, except those inside
+// another index's root so no path is found twice, with the file of each
+func indexRoots() map[string]string {
+	roots := map[string]string{}
+	for _, name := range indexFiles() {
+		if ir, err := openIndex(name); err == nil {
+			roots[ir.root] = name
+			ir.Close()
+		}
+	}
+	for root := range roots {
+		for other := range roots {
+			if other != root && (other == "/" || strings.HasPrefix(root, other+"/")) {
+				delete(roots, root)
+				break
+			}
+		}
+	}
+	return roots
+}
+
+// Search reads each index in turn, then adds what the watcher saw created
+// since. The root and ignore options are left to the query's clauses, like
+// with locate.
+func (indexSearcher) Search(ctx context.Context, patterns []string, opts searchOpts) (<-chan result, error) {
+	match, err := pathMatcher(patterns, opts)
+	if err != nil {
+		return nil, err
+	}
+	roots := indexRoots()
+	if len(roots) == 0 {
+		return nil, errors.New("no index yet, run gocate index with the directories to search")
+	}
+	ov := overlay.Load()
+	results := make(chan result)
+	go func() {
+		defer close(results)
+		for _, root := range slices.Sorted(maps.Keys(roots)) {
+			ir, err := openIndex(roots[root])
+			if err != nil {
+				select {
+				case results <- result{err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			for {
+				e, err := ir.next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					ir.Close()
+					select {
+					case results <- result{err: fmt.Errorf("%s: %w", roots[root], err)}:
+					case <-ctx.Done():
+					}
+					return
+				}
+				if !match(e.path) || ov.hides(e.path) {
+					continue
+				}
+				select {
+				case results <- result{path: e.path}:
+				case <-ctx.Done():
+					ir.Close()
+					return
+				}
+			}
+			ir.Close()
+		}
+		for _, path := range ov.created() {
+			if !match(path) {
+				continue
+			}
+			select {
+			case results <- result{path: path}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results, nil
+}
+
+// indexStats is what one run of the indexer did
+type indexStats struct {
+	root     string
+	paths    int
+	reread   int // directories listed again, the rest were unchanged since last time
+	elapsed  time.Duration
+	fileSize int64
+}
+
+// buildIndex walks root and saves its index, re-reading only the directories
+// whose mtime changed since the last one. Like updatedb it doesn't follow
+// symlinks, skips what it can't read, and leaves out names matching ignore;
+// when ignore isn't what the last index was built with, every directory is
+// read again, as the listings kept would be missing what it no longer hides.
+// It holds off while the user types, and cancelled keeps the index there was.
+func buildIndex(ctx context.Context, root string, ignore []string) (indexStats, error) {
+	start := time.Now()
+	stats := indexStats{root: root}
+	name, err := indexFile(root)
+	if err != nil {
+		return stats, err
+	}
+	old := map[string]indexEntry{}    // directory -> its entry last time
+	children := map[string][]string{} // directory -> what was in it last time
+	previous := 0                     // paths last time, what progress is reported against
+	if ir, err := openIndex(name); err == nil {
+		reuse := ir.knownIgnore && slices.Equal(ir.ignore, ignore)
+		for {
+			e, err := ir.next()
+			if err != nil {
+				break
+			}
+			previous++
+			if !reuse {
+				continue
+			}
+			if e.dir {
+				old[e.path] = e
+			}
+			if e.path != root {
+				parent := filepath.Dir(e.path)
+				children[parent] = append(children[parent], filepath.Base(e.path))
+			}
+		}
+		ir.Close()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return stats, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".index-*")
+	if err != nil {
+		return stats, err
+	}
+	defer os.Remove(tmp.Name()) // gone already once renamed into place
+	z := gzip.NewWriter(tmp)
+	w := bufio.NewWriter(z)
+	ignoreLine, _ := json.Marshal(ignore)
+	fmt.Fprintf(w, "%s\n%s\n%s\n", indexMagic, root, ignoreLine)
+	prev := ""
+	var buf []byte
+	write := func(e indexEntry) {
+		shared := commonPrefixLen(prev, e.path)
+		buf = binary.AppendUvarint(buf[:0], uint64(shared))
+		buf = binary.AppendUvarint(buf, uint64(len(e.path)-shared))
+		buf = append(buf, e.path[shared:]...)
+		if e.dir {
+			buf = append(buf, indexDirFlag)
+			buf = binary.AppendVarint(buf, e.mtime)
+		} else {
+			buf = append(buf, 0)
+		}
+		w.Write(buf)
+		prev = e.path
+		stats.paths++
+		reportProgress(ctx, int64(stats.paths), int64(previous))
+	}
+
+	var walk func(path string, info fs.FileInfo)
+	walk = func(path string, info fs.FileInfo) {
+		if pauseWhileTyping(ctx) != nil {
+			return
+		}
+		if !info.IsDir() {
+			write(indexEntry{path: path})
+			return
+		}
+		e := indexEntry{path: path, dir: true, mtime: info.ModTime().UnixNano()}
+		write(e)
+		if walkPrune[path] {
+			return
+		}
+		names, unchanged := children[path], old[path].mtime == e.mtime && old[path].dir
+		if !unchanged {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return
+			}
+			stats.reread++
+			names = names[:0:0]
+			for _, d := range entries {
+				names = append(names, d.Name())
+			}
+		}
+		for _, n := range names {
+			if ignoredName(ignore, n) {
+				continue
+			}
+			child := filepath.Join(path, n)
+			if info, err := os.Lstat(child); err == nil {
+				walk(child, info)
+			}
+		}
+	}
+	info, err := os.Lstat(root)
+	if err != nil {
+		tmp.Close()
+		return stats, err
+	}
+	walk(root, info)
+
+	err = errors.Join(w.Flush(), z.Close(), tmp.Close(), ctx.Err())
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if fi, statErr := os.Stat(name); err == nil && statErr == nil {
+		stats.fileSize = fi.Size()
+	}
+	stats.elapsed = time.Since(start)
+	return stats, err
+}
+
+func commonPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// reindexAll builds every existing index again, for the update key. Once
+// they're all written, the watcher's changes from before they started are in
+// them, so the overlay forgets those; until then, or if one fails, it keeps them.
+func reindexAll(ctx context.Context, ignore []string) error {
+	seq := 0
+	if o := overlay.Load(); o != nil {
+		seq = o.seq
+	}
+	var errs []error
+	for root := range indexRoots() {
+		if _, err := buildIndex(ctx, root, ignore); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	forgetChanges(seq)
+	return nil
+}
+
+// runIndexCommand is gocate index [dir...]: it indexes each directory given,
+// or builds every existing index again with none, and reports on each
+func runIndexCommand(dirs []string, out io.Writer) error {
+	cfg, _ := loadConfig() // the ignore list, the defaults do without
+	home, _ := os.UserHomeDir()
+	var roots []string
+	for _, d := range dirs {
+		root, err := resolveRoot(d, home)
+		if err != nil {
+			return fmt.Errorf("%s: %w", d, err)
+		}
+		roots = append(roots, cmp.Or(root, "/"))
+	}
+	if len(roots) == 0 {
+		roots = slices.Sorted(maps.Keys(indexRoots()))
+		if len(roots) == 0 {
+			return errors.New("no index yet, give the directories to index, e.g. gocate index ~/")
+		}
+	}
+	for _, root := range roots {
+		stats, err := buildIndex(context.Background(), root, cfg.Ignore)
+		if err != nil {
+			return fmt.Errorf("%s: %w", root, err)
+		}
+		fmt.Fprintf(out, "%s: %d paths, %d directories read, %s in %s\n", stats.root, stats.paths, stats.reread,
+			formatSize(stats.fileSize, false), formatElapsed(stats.elapsed))
+	}
+	return nil
+}
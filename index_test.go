@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// indexTree makes a small tree to index and points the data dir at a
+// temporary one, so the index files the test builds are the only ones there
+func indexTree(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	for _, name := range []string{"docs/report.pdf", "docs/notes.txt", "src/main.go", "src/node_modules/x.js", "README"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// readIndex is every path in root's index file, in the order written
+func readIndex(t *testing.T, root string) []string {
+	t.Helper()
+	name, err := indexFile(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ir, err := openIndex(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ir.Close()
+	if ir.root != root {
+		t.Errorf("index root = %q, want %q", ir.root, root)
+	}
+	var paths []string
+	for {
+		e, err := ir.next()
+		if err == io.EOF {
+			return paths
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info, err := os.Lstat(e.path); err != nil || info.IsDir() != e.dir {
+			t.Errorf("%s: dir flag %v doesn't match the file (%v)", e.path, e.dir, err)
+		}
+		paths = append(paths, e.path)
+	}
+}
+
+// searchIndex runs a search over the indexes and returns what it found, sorted
+func searchIndex(t *testing.T, patterns []string, opts searchOpts) []string {
+	t.Helper()
+	results, err := indexSearcher{}.Search(context.Background(), patterns, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for r := range results {
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		paths = append(paths, r.path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func under(root string, names ...string) []string {
+	paths := make([]string, len(names))
+	for i, n := range names {
+		paths[i] = filepath.Join(root, n)
+	}
+	return paths
+}
+
+func TestBuildIndex(t *testing.T) {
+	root := indexTree(t)
+	stats, err := buildIndex(context.Background(), root, []string{"node_modules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := under(root, "", "README", "docs", "docs/notes.txt", "docs/report.pdf", "src", "src/main.go")
+	got := readIndex(t, root)
+	if !slices.Equal(slices.Sorted(slices.Values(got)), want) {
+		t.Errorf("index holds %q, want %q", got, want)
+	}
+	if got[0] != root {
+		t.Errorf("index starts with %q, want the root", got[0])
+	}
+	if stats.paths != len(want) || stats.reread != 3 || stats.fileSize == 0 {
+		t.Errorf("stats = %+v, want %d paths and 3 directories read", stats, len(want))
+	}
+
+	tests := []struct {
+		patterns []string
+		opts     searchOpts
+		want     []string
+	}{
+		{[]string{"report"}, searchOpts{}, under(root, "docs/report.pdf")},
+		{[]string{"docs"}, searchOpts{}, under(root, "docs", "docs/notes.txt", "docs/report.pdf")},
+		{[]string{"docs"}, searchOpts{basename: true}, under(root, "docs")},
+		{[]string{"docs", ".txt"}, searchOpts{}, under(root, "docs/notes.txt")},
+		{[]string{"readme"}, searchOpts{}, nil},
+		{[]string{"readme"}, searchOpts{ignoreCase: true}, under(root, "README")},
+		{[]string{`\.(go|pdf)$`}, searchOpts{regex: true}, under(root, "docs/report.pdf", "src/main.go")},
+		{[]string{"x.js"}, searchOpts{}, nil},
+	}
+	for _, tt := range tests {
+		if got := searchIndex(t, tt.patterns, tt.opts); !slices.Equal(got, tt.want) {
+			t.Errorf("search %q %+v = %q, want %q", tt.patterns, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestBuildIndexIncremental(t *testing.T) {
+	root := indexTree(t)
+	if _, err := buildIndex(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing changed, so no directory is listed again
+	stats, err := buildIndex(context.Background(), root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.reread != 0 || stats.paths != 9 {
+		t.Errorf("unchanged tree: stats = %+v, want 9 paths and no directories read", stats)
+	}
+
+	// A file added with the directory's mtime put back isn't seen, which shows
+	// the listing came from the last index rather than the disk
+	docs := filepath.Join(root, "docs")
+	before, err := os.Stat(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hidden := filepath.Join(docs, "hidden.txt")
+	if err := os.WriteFile(hidden, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(docs, time.Now(), before.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := buildIndex(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(readIndex(t, root), hidden) {
+		t.Errorf("%s was listed though its directory's mtime didn't change", hidden)
+	}
+
+	// Only the directory whose mtime changed is listed again
+	added := filepath.Join(root, "src", "util.go")
+	if err := os.WriteFile(added, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(root, "src"), time.Now(), time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err = buildIndex(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+	if stats.reread != 1 || stats.paths != 10 {
+		t.Errorf("one directory changed: stats = %+v, want 10 paths and 1 directory read", stats)
+	}
+	if got := searchIndex(t, []string{"util"}, searchOpts{}); !slices.Equal(got, []string{added}) {
+		t.Errorf("search util = %q, want %q", got, added)
+	}
+
+	// Removed files are dropped along with their directory's new listing
+	if err := os.Remove(filepath.Join(root, "docs", "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := buildIndex(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIndex(t, []string{"notes"}, searchOpts{}); got != nil {
+		t.Errorf("search notes after removing it = %q, want nothing", got)
+	}
+}
+
+func TestBuildIndexIgnoreChanged(t *testing.T) {
+	root := indexTree(t)
+	if _, err := buildIndex(context.Background(), root, []string{"node_modules"}); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing on disk changed, but the listings kept left node_modules out
+	stats, err := buildIndex(context.Background(), root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.reread != 4 {
+		t.Errorf("ignore list changed: %d directories read, want all 4", stats.reread)
+	}
+	want := filepath.Join(root, "src", "node_modules", "x.js")
+	if got := searchIndex(t, []string{"x.js"}, searchOpts{}); !slices.Equal(got, []string{want}) {
+		t.Errorf("search x.js after no longer ignoring node_modules = %q, want %q", got, want)
+	}
+
+	// The same list again reuses the listings
+	if stats, err = buildIndex(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+	if stats.reread != 0 {
+		t.Errorf("same ignore list: %d directories read, want none", stats.reread)
+	}
+}
+
+func TestBuildIndexCancelled(t *testing.T) {
+	root := indexTree(t)
+	if _, err := buildIndex(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "new.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := buildIndex(ctx, root, nil); err == nil {
+		t.Error("cancelled build succeeded")
+	}
+	if got := searchIndex(t, []string{"new.txt"}, searchOpts{}); got != nil {
+		t.Errorf("cancelled build replaced the index, found %q", got)
+	}
+}
+
+func TestReindexAllOverlay(t *testing.T) {
+	root := indexTree(t)
+	t.Cleanup(func() { overlay.Store(nil) })
+	if _, err := buildIndex(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+	added := filepath.Join(root, "docs", "added.txt")
+	if err := os.WriteFile(added, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	applyChanges([]indexChange{{path: added}})
+
+	// The new index holds what the watcher saw, so the overlay is let go of
+	if err := reindexAll(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if o := overlay.Load(); o != nil {
+		t.Errorf("overlay after reindexing = %+v, want none", o)
+	}
+	if got := searchIndex(t, []string{"added"}, searchOpts{}); !slices.Equal(got, []string{added}) {
+		t.Errorf("search added after reindexing = %q, want %q", got, added)
+	}
+
+	// A failed reindex keeps the changes, the old index doesn't have them
+	later := filepath.Join(root, "docs", "later.txt")
+	applyChanges([]indexChange{{path: later}})
+	if err := os.RemoveAll(root); err != nil {
+		t.Fatal(err)
+	}
+	if err := reindexAll(context.Background(), nil); err == nil {
+		t.Fatal("reindexing a removed root succeeded")
+	}
+	if got := searchIndex(t, []string{"later"}, searchOpts{}); !slices.Equal(got, []string{later}) {
+		t.Errorf("search later after a failed reindex = %q, want %q", got, later)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+// With watch_index set, gocate watches every directory under the index roots
+// while it runs and keeps what was created and removed since in an overlay
+// the index searcher applies on top of the index files, so results don't go
+// stale until the next gocate index. inotify watches aren't recursive, so
+// that's one watch per directory, as many as fs.inotify.max_user_watches allows.
+
+const (
+	indexWatchDelay = 200 * time.Millisecond // quiet time for a burst of events to arrive as one batch
+	indexWatchGap   = 2 * time.Second        // at least this long between batches, however busy the disk
+)
+
+// indexOverlay is what changed under the index roots since the index files
+// were written. Each change is numbered, so a path created again after it or
+// a directory above it was removed shows, while the old one doesn't. It is
+// never changed once stored, searches read it without locking.
+type indexOverlay struct {
+	seq     int
+	added   map[string]int // created since, to the change that did
+	removed map[string]int // deleted or renamed away since, with everything under them
+}
+
+var overlay atomic.Pointer[indexOverlay]
+
+// removedAfter reports whether path, or a directory above it, was removed by a change after seq
+func (o *indexOverlay) removedAfter(path string, seq int) bool {
+	if o == nil || len(o.removed) == 0 {
+		return false
+	}
+	for p := path; ; p = filepath.Dir(p) {
+		if s, ok := o.removed[p]; ok && s > seq {
+			return true
+		}
+		if p == "/" || p == "." {
+			return false
+		}
+	}
+}
+
+// hides reports whether an indexed path is gone, or will be listed with the
+// paths created since instead
+func (o *indexOverlay) hides(path string) bool {
+	if o == nil {
+		return false
+	}
+	_, added := o.added[path]
+	return added || o.removedAfter(path, 0)
+}
+
+// created are the paths created since and still there, in order
+func (o *indexOverlay) created() []string {
+	if o == nil {
+		return nil
+	}
+	var paths []string
+	for _, p := range slices.Sorted(maps.Keys(o.added)) {
+		if !o.removedAfter(p, o.added[p]) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// indexChange is one path created or removed, as the watcher saw it
+type indexChange struct {
+	path    string
+	removed bool
+}
+
+// applyChanges stores a new overlay with changes on top of the current one
+func applyChanges(changes []indexChange) {
+	for {
+		o := overlay.Load()
+		next := &indexOverlay{added: map[string]int{}, removed: map[string]int{}}
+		if o != nil {
+			next.seq, next.added, next.removed = o.seq, maps.Clone(o.added), maps.Clone(o.removed)
+		}
+		for _, c := range changes {
+			next.seq++
+			if c.removed {
+				delete(next.added, c.path)
+				next.removed[c.path] = next.seq
+			} else {
+				next.added[c.path] = next.seq
+			}
+		}
+		if overlay.CompareAndSwap(o, next) { // unless a reindex trimmed it meanwhile
+			return
+		}
+	}
+}
+
+// forgetChanges drops the changes up to seq from the overlay, once new index
+// files include them, keeping those that came after
+func forgetChanges(seq int) {
+	for {
+		o := overlay.Load()
+		if o == nil {
+			return
+		}
+		next := &indexOverlay{seq: o.seq, added: map[string]int{}, removed: map[string]int{}}
+		for p, s := range o.added {
+			if s > seq {
+				next.added[p] = s
+			}
+		}
+		for p, s := range o.removed {
+			if s > seq {
+				next.removed[p] = s
+			}
+		}
+		if len(next.added) == 0 && len(next.removed) == 0 {
+			next = nil
+		}
+		if overlay.CompareAndSwap(o, next) {
+			return
+		}
+	}
+}
+
+// indexWatchMsg tells the model the overlay changed, or that watching ran into trouble
+type indexWatchMsg struct {
+	next <-chan indexWatchMsg
+	err  error
+}
+
+// waitIndexWatch reads the watcher's next message, nil when nothing is watched
+func waitIndexWatch(ch <-chan indexWatchMsg) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg { return <-ch }
+}
+
+// indexWatcher is the state of the watching goroutine
+type indexWatcher struct {
+	w       *fsnotify.Watcher
+	ignore  []string
+	watched map[string]bool
+}
+
+// add watches dir, except what updatedb would prune
+func (iw *indexWatcher) add(dir string) error {
+	if walkPrune[dir] || iw.watched[dir] {
+		return nil
+	}
+	if err := iw.w.Add(dir); err != nil {
+		return err
+	}
+	iw.watched[dir] = true
+	return nil
+}
+
+// forget drops the watches on dir and under it once it's removed or renamed,
+// so a directory moved elsewhere doesn't go on reporting its old paths
+func (iw *indexWatcher) forget(dir string) {
+	if !iw.watched[dir] {
+		return
+	}
+	for d := range iw.watched {
+		if d == dir || strings.HasPrefix(d, dir+"/") {
+			iw.w.Remove(d) // already gone with the directory when it was deleted
+			delete(iw.watched, d)
+		}
+	}
+}
+
+// watchIndexed watches each directory in the indexes, stopping short with an
+// error when the system runs out of watches
+func (iw *indexWatcher) watchIndexed() error {
+	roots := indexRoots()
+	var dirs []string
+	for _, root := range slices.Sorted(maps.Keys(roots)) {
+		ir, err := openIndex(roots[root])
+		if err != nil {
+			return err
+		}
+		for {
+			e, err := ir.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				ir.Close()
+				return fmt.Errorf("%s: %w", roots[root], err)
+			}
+			if e.dir {
+				dirs = append(dirs, e.path)
+			}
+		}
+		ir.Close()
+	}
+	for i, dir := range dirs {
+		err := iw.add(dir)
+		if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE) {
+			return fmt.Errorf("only %d of %d directories are followed, raise fs.inotify.max_user_watches for the rest", i, len(dirs))
+		} // other directories that can't be watched are gone or unreadable
+	}
+	return nil
+}
+
+// changes turns an event into changes to the overlay. A directory created
+// may have filled up before its watch was added, so it is walked at once.
+func (iw *indexWatcher) changes(e fsnotify.Event) []indexChange {
+	if ignoredName(iw.ignore, filepath.Base(e.Name)) {
+		return nil
+	}
+	switch {
+	case e.Has(fsnotify.Remove) || e.Has(fsnotify.Rename): // a rename's new name arrives as a create
+		iw.forget(e.Name)
+		return []indexChange{{path: e.Name, removed: true}}
+	case e.Has(fsnotify.Create):
+		var changes []indexChange
+		filepath.WalkDir(e.Name, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // gone again already, or unreadable like updatedb skips
+			}
+			if path != e.Name && ignoredName(iw.ignore, d.Name()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			changes = append(changes, indexChange{path: path})
+			if d.IsDir() {
+				iw.add(path)
+			}
+			return nil
+		})
+		return changes
+	}
+	return nil // writes and mode changes don't change what's found
+}
+
+// watchIndex starts following changes under the index roots. Changes are
+// gathered into batches, applied to the overlay and the model told once per
+// batch, so heavy IO costs at most one search every indexWatchGap.
+func watchIndex(ignore []string) <-chan indexWatchMsg {
+	ch := make(chan indexWatchMsg, 1)
+	report := func(err error) { ch <- indexWatchMsg{next: ch, err: err} }
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		go report(err)
+		return ch
+	}
+	go func() {
+		defer w.Close()
+		iw := &indexWatcher{w: w, ignore: ignore, watched: map[string]bool{}}
+		if err := iw.watchIndexed(); err != nil {
+			report(err)
+		}
+		var pending []indexChange
+		var flush <-chan time.Time
+		var last time.Time
+		for {
+			select {
+			case e, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				pending = append(pending, iw.changes(e)...)
+				if flush == nil && len(pending) > 0 {
+					flush = time.After(max(indexWatchDelay, indexWatchGap-time.Since(last)))
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				if errors.Is(err, fsnotify.ErrEventOverflow) {
+					err = errors.New("too many changes at once to follow, update the index to catch up")
+				}
+				report(err)
+			case <-flush:
+				applyChanges(pending)
+				pending, flush, last = nil, nil, time.Now()
+				select {
+				case ch <- indexWatchMsg{next: ch}:
+				default: // the model hasn't read the last one yet, it will search then
+				}
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// initialsRegex is what plocate is given for an initials term: its letters in
+// order within the file name, so plocate finds every candidate and
+// initialsFilter keeps the ones where they start words
+func initialsRegex(term string) string {
+	var parts []string
+	for _, r := range term {
+		if isWordRune(r) {
+			parts = append(parts, regexp.QuoteMeta(string(r)))
+		}
+	}
+	return strings.Join(parts, "[^/]*") + "[^/]*$"
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// nameWords splits a file name into the words of its camelCase, snake_case or
+// kebab-case parts, lowercased: "HTTPServer_test.go" is http, server, test, go
+func nameWords(name string) []string {
+	var words []string
+	r := []rune(name)
+	start := -1
+	for i, c := range r {
+		if !isWordRune(c) {
+			if start >= 0 {
+				words = append(words, string(r[start:i]))
+			}
+			start = -1
+			continue
+		}
+		if start >= 0 {
+			prev := r[i-1]
+			next := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if unicode.IsUpper(c) && (unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && next) ||
+				unicode.IsDigit(c) != unicode.IsDigit(prev) {
+				words = append(words, string(r[start:i]))
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(r[start:]))
+	}
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return words
+}
+
+// matchesInitials is whether term can be read off the starts of words, in
+// order and skipping any: "fbC" and "fooBC" both match fooBarController.go,
+// as does "sc" snake_case.go. Case and separators in term don't matter.
+func matchesInitials(words []string, term string) bool {
+	if term == "" {
+		return true
+	}
+	for i, w := range words {
+		for k := min(len(w), len(term)); k > 0; k-- {
+			if strings.HasPrefix(term, w[:k]) && matchesInitials(words[i+1:], term[k:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// initialsFilter keeps the paths whose file name matches every term of one of
+// the query's alternatives by initials
+func initialsFilter(alts [][]string) filter {
+	var folded [][]string
+	for _, terms := range alts {
+		var fold []string
+		for _, t := range terms {
+			fold = append(fold, strings.ToLower(strings.Join(strings.FieldsFunc(t, func(r rune) bool { return !isWordRune(r) }), "")))
+		}
+		folded = append(folded, fold)
+	}
+	return filter{keep: func(path string, _ os.FileInfo) bool {
+		words := nameWords(filepath.Base(path))
+		return slices.ContainsFunc(folded, func(terms []string) bool {
+			return !slices.ContainsFunc(terms, func(t string) bool { return !matchesInitials(words, t) })
+		})
+	}}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNameWords(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"HTTPServer_test.go", []string{"http", "server", "test", "go"}},
+		{"fooBarController.go", []string{"foo", "bar", "controller", "go"}},
+		{"snake_case-and-kebab", []string{"snake", "case", "and", "kebab"}},
+		{"base64Encode", []string{"base", "64", "encode"}},
+		{"README", []string{"readme"}},
+		{"__init__.py", []string{"init", "py"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := nameWords(tt.name); !slices.Equal(got, tt.want) {
+			t.Errorf("nameWords(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInitialsFilter(t *testing.T) {
+	tests := []struct {
+		terms []string
+		path  string
+		match bool
+	}{
+		{[]string{"fbc"}, "/src/fooBarController.go", true},
+		{[]string{"fooBC"}, "/src/fooBarController.go", true},
+		{[]string{"FBC"}, "/src/fooBarController.go", true},
+		{[]string{"fc"}, "/src/fooBarController.go", true},   // words can be skipped
+		{[]string{"bfc"}, "/src/fooBarController.go", false}, // but not reordered
+		{[]string{"fob"}, "/src/fooBarController.go", true},
+		{[]string{"fxb"}, "/src/fooBarController.go", false},
+		{[]string{"oo"}, "/src/fooBarController.go", false}, // only from the start of a word
+		{[]string{"sc"}, "/x/snake_case.go", true},
+		{[]string{"s_c"}, "/x/snake_case.go", true}, // separators in the term don't matter
+		{[]string{"hs"}, "/x/HTTPServer.go", true},
+		{[]string{"hts"}, "/x/HTTPServer.go", true},
+		{[]string{"fbc"}, "/fooBarController/x.go", false}, // the file name only
+		{[]string{"fbc", "go"}, "/src/fooBarController.go", true},
+		{[]string{"fbc", "py"}, "/src/fooBarController.go", false}, // every term
+	}
+	for _, tt := range tests {
+		if _, got := (query{filters: []filter{initialsFilter([][]string{tt.terms})}}).keep(tt.path); got != tt.match {
+			t.Errorf("initials %q matching %q = %v, want %v", tt.terms, tt.path, got, tt.match)
+		}
+	}
+
+	// A path is kept when it matches any of the alternatives
+	f := initialsFilter([][]string{{"xyz"}, {"fbc"}})
+	if !f.keep("/src/fooBarController.go", nil) {
+		t.Error("initials xyz | fbc didn't match fooBarController.go")
+	}
+}
+
+func TestInitialsRegex(t *testing.T) {
+	// the way plocate runs it, as a regex ignoring case
+	match, err := pathMatcher([]string{initialsRegex("f_bC")}, searchOpts{regex: true, ignoreCase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"/src/fooBarController.go": true,
+		"/src/fbc":                 true,
+		"/fooBar/Controller.go":    false, // the letters must all be in the file name
+		"/src/bfc":                 false,
+	} {
+		if got := match(path); got != want {
+			t.Errorf("initialsRegex(f_bC) matching %q = %v, want %v", path, got, want)
+		}
+	}
+}
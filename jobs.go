@@ -0,0 +1,393 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// jobKind groups background work sharing a limit on how much of it runs at once
+type jobKind string
+
+const (
+	jobStat  jobKind = "stat"
+	jobHash  jobKind = "hash"  // checksums and comparing files
+	jobIndex jobKind = "index" // rebuilding gocate's own index
+	jobCopy  jobKind = "copy"  // sending files, tracked but not queued, there's one at a time
+	jobPipe  jobKind = "pipe"  // piping every match to a command
+	jobPerms jobKind = "perms" // changing modes and owners
+)
+
+// jobLimits is how many jobs of each kind run at once, the rest wait in turn
+var jobLimits = map[jobKind]int{
+	jobStat:  16,
+	jobHash:  max(runtime.NumCPU()/2, 1), // each hashes a file per CPU already
+	jobIndex: 1,
+	jobPipe:  1,
+	jobPerms: 1,
+}
+
+// job is one piece of background work, queued or running
+type job struct {
+	id       int
+	kind     jobKind
+	label    string
+	queued   time.Time
+	started  time.Time // zero while queued
+	cancel   context.CancelFunc
+	progress float64 // how much is done, from 0 to 1, or -1 until the job says
+	paused   bool    // waiting for the user to stop typing
+}
+
+// jobManager runs the model's background work through a bounded queue per
+// kind, so e.g. a page of stats can't starve a checksum, and keeps track of it
+// for the jobs dialog. It's shared by every copy of the model.
+type jobManager struct {
+	mu      sync.Mutex
+	next    int
+	jobs    []*job // in the order they were queued
+	slots   map[jobKind]chan struct{}
+	ticking atomic.Bool  // a jobsProgressMsg is on its way
+	typed   atomic.Int64 // when the query last changed, in Unix nanoseconds
+}
+
+func newJobManager() *jobManager {
+	jm := &jobManager{slots: map[jobKind]chan struct{}{}}
+	for kind, n := range jobLimits {
+		jm.slots[kind] = make(chan struct{}, n)
+	}
+	return jm
+}
+
+// jobCancelledMsg reports a job cancelled before it finished
+type jobCancelledMsg struct {
+	kind  jobKind
+	label string
+}
+
+// run queues fn as a job of kind. The command returns what fn does once it
+// gets a turn, which waits for the user to stop typing, or a jobCancelledMsg
+// when cancelled first or meanwhile, fn being expected to give up once ctx is
+// done.
+func (jm *jobManager) run(kind jobKind, label string, fn func(ctx context.Context) tea.Msg) tea.Cmd {
+	ctx, j := jm.add(kind, label)
+	cancel := j.cancel
+	return func() tea.Msg {
+		defer jm.remove(j)
+		defer cancel()
+		if pauseWhileTyping(ctx) != nil {
+			return jobCancelledMsg{kind, label}
+		}
+		select {
+		case jm.slots[kind] <- struct{}{}:
+			defer func() { <-jm.slots[kind] }()
+		case <-ctx.Done():
+			return jobCancelledMsg{kind, label}
+		}
+		jm.mu.Lock()
+		j.started = time.Now()
+		jm.mu.Unlock()
+		msg := fn(ctx)
+		if ctx.Err() != nil {
+			return jobCancelledMsg{kind, label}
+		}
+		return msg
+	}
+}
+
+// add lists a new job, queued, with the context it runs with
+func (jm *jobManager) add(kind jobKind, label string) (context.Context, *job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	jm.next++
+	j := &job{id: jm.next, kind: kind, label: label, queued: time.Now(), cancel: cancel, progress: -1}
+	jm.jobs = append(jm.jobs, j)
+	return context.WithValue(ctx, jobKey{}, jobRef{jm, j}), j
+}
+
+// track lists work that runs outside the queue, e.g. a send's process, as
+// running. Cancelling it cancels ctx; done takes it off the list.
+func (jm *jobManager) track(kind jobKind, label string) (ctx context.Context, done func()) {
+	ctx, j := jm.add(kind, label)
+	jm.mu.Lock()
+	j.started = j.queued
+	jm.mu.Unlock()
+	return ctx, func() {
+		j.cancel()
+		jm.remove(j)
+	}
+}
+
+// jobKey is where a job's context holds its jobRef
+type jobKey struct{}
+
+// jobRef is the job a context runs, for the job to report to its manager
+type jobRef struct {
+	jm *jobManager
+	j  *job
+}
+
+// reportProgress records how far the job running with ctx got, done of total
+// in whatever unit suits it, for the jobs dialog and the footer
+func reportProgress(ctx context.Context, done, total int64) {
+	if ref, ok := ctx.Value(jobKey{}).(jobRef); ok && total > 0 {
+		ref.jm.mu.Lock()
+		ref.j.progress = min(float64(done)/float64(total), 1)
+		ref.jm.mu.Unlock()
+	}
+}
+
+// typingPause is how long after the query last changed background work waits,
+// so stats, hashes and indexing on a slow disk don't hold up the keystrokes
+const typingPause = 400 * time.Millisecond
+
+// typing tells the jobs the user is typing a query
+func (jm *jobManager) typing() {
+	jm.typed.Store(time.Now().UnixNano())
+}
+
+// pauseWhileTyping waits until the user hasn't typed for typingPause, if ctx
+// runs a job, for long work to call between steps. It's only an atomic load
+// when nobody is typing. The error is ctx's once cancelled meanwhile.
+func pauseWhileTyping(ctx context.Context) error {
+	ref, ok := ctx.Value(jobKey{}).(jobRef)
+	if !ok || time.Since(time.Unix(0, ref.jm.typed.Load())) >= typingPause {
+		return ctx.Err()
+	}
+	for ctx.Err() == nil {
+		wait := typingPause - time.Since(time.Unix(0, ref.jm.typed.Load()))
+		if wait <= 0 {
+			break
+		}
+		ref.jm.setPaused(ref.j, true)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+		}
+	}
+	ref.jm.setPaused(ref.j, false)
+	return ctx.Err()
+}
+
+func (jm *jobManager) setPaused(j *job, paused bool) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	j.paused = paused
+}
+
+func (jm *jobManager) remove(j *job) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	for i, other := range jm.jobs {
+		if other == j {
+			jm.jobs = append(jm.jobs[:i:i], jm.jobs[i+1:]...)
+			return
+		}
+	}
+}
+
+// cancel stops the job with id, if it hasn't finished
+func (jm *jobManager) cancel(id int) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	for _, j := range jm.jobs {
+		if j.id == id {
+			j.cancel()
+		}
+	}
+}
+
+// cancelQueued drops the jobs of kind still waiting for a turn, e.g. the stats
+// of rows a new search replaced
+func (jm *jobManager) cancelQueued(kind jobKind) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	for _, j := range jm.jobs {
+		if j.kind == kind && j.started.IsZero() {
+			j.cancel()
+		}
+	}
+}
+
+// snapshot copies the jobs as they are, running ones first
+func (jm *jobManager) snapshot() []job {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	var running, queued []job
+	for _, j := range jm.jobs {
+		if j.started.IsZero() {
+			queued = append(queued, *j)
+		} else {
+			running = append(running, *j)
+		}
+	}
+	return append(running, queued...)
+}
+
+// overall is the mean progress of the running jobs that report any, -1 if
+// none has yet, and how many jobs other than stats there are
+func (jm *jobManager) overall() (float64, int) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	sum, reporting, n := 0.0, 0, 0
+	for _, j := range jm.jobs {
+		if j.kind == jobStat {
+			continue // too quick and too many to be worth a bar
+		}
+		n++
+		if j.progress >= 0 {
+			sum += j.progress
+			reporting++
+		}
+	}
+	if reporting == 0 {
+		return -1, n
+	}
+	return sum / float64(reporting), n
+}
+
+// progressInterval is how often progress is redrawn while there's any
+const progressInterval = 250 * time.Millisecond
+
+// jobsProgressMsg redraws progress bars
+type jobsProgressMsg struct{}
+
+// watchProgress starts redrawing progress bars for as long as there are jobs
+// other than stats, unless that's happening already. Call it after queueing one.
+func (jm *jobManager) watchProgress() tea.Cmd {
+	if !jm.ticking.CompareAndSwap(false, true) {
+		return nil
+	}
+	return tea.Tick(progressInterval, func(time.Time) tea.Msg { return jobsProgressMsg{} })
+}
+
+// nextProgress goes on redrawing after a jobsProgressMsg, or stops once the jobs are done
+func (jm *jobManager) nextProgress() tea.Cmd {
+	if _, n := jm.overall(); n == 0 {
+		jm.ticking.Store(false)
+		return nil
+	}
+	return tea.Tick(progressInterval, func(time.Time) tea.Msg { return jobsProgressMsg{} })
+}
+
+// progressBar draws a job's bar, or the footer's, without the animation
+// bubbles/progress does for a bar kept in a model
+func progressBar(f float64, width int) string {
+	bar := progress.New(progress.WithSolidFill("#3e6589"), progress.WithWidth(width))
+	return bar.ViewAs(f)
+}
+
+var (
+	jobUp     = key.NewBinding(key.WithKeys("up", "ctrl+p"), key.WithHelp("↑/↓", "select"))
+	jobDown   = key.NewBinding(key.WithKeys("down", "ctrl+n"))
+	jobCancel = key.NewBinding(key.WithKeys("ctrl+d", "delete"), key.WithHelp("ctrl+d", "cancel job"))
+)
+
+// jobsShown is how many jobs the dialog lists before summing up the rest
+const jobsShown = 15
+
+// jobsTickMsg redraws the jobs dialog so running times count up, ticking for
+// as long as the dialog it was started for is open
+type jobsTickMsg struct {
+	opened time.Time
+}
+
+func jobsTick(opened time.Time) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return jobsTickMsg{opened} })
+}
+
+// jobsDialog lists what's running and queued, and cancels the selected job
+type jobsDialog struct {
+	jobs       *jobManager
+	selectedID int // the id of the selected job, so it stays selected as others finish
+	opened     time.Time
+}
+
+func newJobsDialog(jm *jobManager) (jobsDialog, tea.Cmd) {
+	d := jobsDialog{jobs: jm, opened: time.Now()}
+	return d, jobsTick(d.opened)
+}
+
+func (d jobsDialog) Update(msg tea.Msg) (dialog, tea.Cmd) {
+	jobs := d.jobs.snapshot()
+	at := d.selected(jobs)
+	switch msg := msg.(type) {
+	case jobsTickMsg:
+		if msg.opened.Equal(d.opened) {
+			return d, jobsTick(d.opened)
+		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, jobUp) && at > 0:
+			d.selectedID = jobs[at-1].id
+		case key.Matches(msg, jobDown) && at < len(jobs)-1:
+			d.selectedID = jobs[at+1].id
+		case key.Matches(msg, jobCancel) && at >= 0:
+			d.jobs.cancel(jobs[at].id)
+		}
+	}
+	return d, nil
+}
+
+// selected is where the selected job is in jobs, the first one if it's gone
+// or none was picked, -1 with no jobs
+func (d jobsDialog) selected(jobs []job) int {
+	for i, j := range jobs {
+		if j.id == d.selectedID {
+			return i
+		}
+	}
+	if len(jobs) == 0 {
+		return -1
+	}
+	return 0
+}
+
+func (d jobsDialog) View() string {
+	jobs := d.jobs.snapshot()
+	at := d.selected(jobs)
+	var b strings.Builder
+	b.WriteString(dialogTitleStyle.Render("Jobs") + "\n\n")
+	if len(jobs) == 0 {
+		b.WriteString(settingsDimStyle.Render("Nothing running") + "\n")
+	}
+	first := max(min(at-jobsShown/2, len(jobs)-jobsShown), 0)
+	for i := first; i < min(first+jobsShown, len(jobs)); i++ {
+		j := jobs[i]
+		state := "queued " + formatElapsed(time.Since(j.queued))
+		switch {
+		case j.paused:
+			state = "paused " + formatElapsed(time.Since(cmp.Or(j.started, j.queued)))
+		case !j.started.IsZero():
+			state = "running " + formatElapsed(time.Since(j.started))
+		}
+		line := fmt.Sprintf("%-5s %-14s %s", j.kind, state, truncateLeft(j.label, 50))
+		if j.progress >= 0 {
+			elapsed := formatElapsed(time.Since(j.started))
+			if j.paused {
+				elapsed = "paused"
+			}
+			line = fmt.Sprintf("%-5s %s %-6s %s", j.kind, progressBar(j.progress, 20), elapsed, truncateLeft(j.label, 50))
+		}
+		if i == at {
+			b.WriteString(settingsCursorStyle.Render("› "+line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	if hidden := len(jobs) - min(jobsShown, len(jobs)); hidden > 0 {
+		b.WriteString(settingsDimStyle.Render(fmt.Sprintf("  %d more", hidden)) + "\n")
+	}
+	return b.String() + "\n" + help.New().ShortHelpView([]key.Binding{jobUp, jobCancel, closeDialog})
+}
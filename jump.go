@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// rowJump is :<n><enter>, which moves the cursor to row n in compact mode where rows are numbered
+type rowJump struct {
+	active bool
+	digits string
+}
+
+// jumpKey handles keys while a jump is being typed. A colon only starts one
+// at the start of the input or after a space, so owner: and friends still type.
+func (m *model) jumpKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	if !m.jump.active {
+		if m.compact && !msg.Paste && msg.Type == tea.KeyRunes && string(msg.Runes) == ":" && m.colonStartsJump() {
+			m.jump = rowJump{active: true}
+			return true, nil
+		}
+		return false, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyRunes:
+		if r := msg.Runes; len(r) == 1 && unicode.IsDigit(r[0]) {
+			m.jump.digits += string(r)
+			return true, nil
+		}
+	case tea.KeyBackspace:
+		if m.jump.digits == "" {
+			m.jump = rowJump{}
+		} else {
+			m.jump.digits = m.jump.digits[:len(m.jump.digits)-1]
+		}
+		return true, nil
+	case tea.KeyEnter:
+		n, _ := strconv.Atoi(m.jump.digits)
+		m.jump = rowJump{}
+		if n < 1 || n > len(m.rows) {
+			return true, notify(toastWarn, fmt.Sprintf("No row %d", n))
+		}
+		m.table.SetCursor(n - 1)
+		return true, nil
+	case tea.KeyEsc:
+		m.jump = rowJump{}
+		return true, nil
+	}
+	m.jump = rowJump{} // anything else gives up on the jump and acts as usual
+	return false, nil
+}
+
+func (m model) colonStartsJump() bool {
+	value, pos := []rune(m.textInput.Value()), m.textInput.Position()
+	return pos == 0 || unicode.IsSpace(value[pos-1])
+}
+
+// numberWidth is how many digits the last row number takes, at least two so the column rarely resizes
+func numberWidth(rows int) int {
+	return max(len(strconv.Itoa(rows)), 2)
+}
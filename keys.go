@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type keyMap struct {
+	Copy, QuickSelect, Focus, SwitchPane, SetRegister, JumpRegister, Refine, Prefix, Mark, Checksum, Diff, Send, Share, Siblings, Pane, Elevate, SaveSearch, Searches, History, Root, Live, Watch, Content, Ignore, Pipe, Batch, Permissions, MatchMode, IgnoreCase, Basename, Compact, Clear, Units, Sort, Profile, UpdateDB, Jobs, Settings, About, Help, Quit key.Binding
+}
+
+var keys = keyMap{
+	Copy: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "copy path")),
+	QuickSelect: key.NewBinding(key.WithKeys("alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9"),
+		key.WithHelp("alt+1…9", "pick visible row")),
+	Focus:        key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "results")),
+	SwitchPane:   key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "other pane")),
+	SetRegister:  key.NewBinding(key.WithKeys("m"), key.WithHelp("m a…z", "remember row")),
+	JumpRegister: key.NewBinding(key.WithKeys("'"), key.WithHelp("' a…z", "go back to row")),
+	Refine:       key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "narrow")),
+	Prefix:       key.NewBinding(key.WithKeys("alt+/"), key.WithHelp("alt+/", "path prefix")),
+	Mark:         key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "mark")),
+	Checksum:     key.NewBinding(key.WithKeys("ctrl+k"), key.WithHelp("ctrl+k", "sha256 marked")),
+	Diff:         key.NewBinding(key.WithKeys("alt+d"), key.WithHelp("alt+d", "diff marked")),
+	Send:         key.NewBinding(key.WithKeys("alt+s"), key.WithHelp("alt+s", "send")),
+	Share:        key.NewBinding(key.WithKeys("alt+e"), key.WithHelp("alt+e", "share")),
+	Siblings:     key.NewBinding(key.WithKeys("ctrl+l"), key.WithHelp("ctrl+l", "same folder")),
+	Pane:         key.NewBinding(key.WithKeys("alt+o"), key.WithHelp("alt+o", "folder pane")),
+	Elevate:      key.NewBinding(key.WithKeys("alt+u"), key.WithHelp("alt+u", "read as root")),
+	SaveSearch:   key.NewBinding(key.WithKeys("alt+v"), key.WithHelp("alt+v", "save search")),
+	Searches:     key.NewBinding(key.WithKeys("ctrl+g"), key.WithHelp("ctrl+g", "saved searches")),
+	History:      key.NewBinding(key.WithKeys("alt+h"), key.WithHelp("alt+h", "query history")),
+	Root:         key.NewBinding(key.WithKeys("alt+r"), key.WithHelp("alt+r", "search under")),
+	Live:         key.NewBinding(key.WithKeys("alt+l"), key.WithHelp("alt+l", "live search")),
+	Watch:        key.NewBinding(key.WithKeys("alt+w"), key.WithHelp("alt+w", "watch")),
+	Content:      key.NewBinding(key.WithKeys("alt+g"), key.WithHelp("alt+g", "content search")),
+	Ignore:       key.NewBinding(key.WithKeys("alt+i"), key.WithHelp("alt+i", "ignore list")),
+	Pipe:         key.NewBinding(key.WithKeys("alt+p"), key.WithHelp("alt+p", "pipe results")),
+	Batch:        key.NewBinding(key.WithKeys("alt+x"), key.WithHelp("alt+x", "run for each")),
+	Permissions:  key.NewBinding(key.WithKeys("alt+m"), key.WithHelp("alt+m", "chmod/chown")),
+	MatchMode:    key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", "regex")),
+	IgnoreCase:   key.NewBinding(key.WithKeys("alt+c"), key.WithHelp("alt+c", "ignore case")),
+	Basename:     key.NewBinding(key.WithKeys("alt+n"), key.WithHelp("alt+n", "names only")),
+	Compact:      key.NewBinding(key.WithKeys("ctrl+t"), key.WithHelp("ctrl+t", "compact")),
+	Clear:        key.NewBinding(key.WithKeys("alt+ctrl+h"), key.WithHelp("ctrl+alt+⌫", "clear")), // need to test this on more systems (seems to be ctrl+alt+backspace on mine?)
+	Units:        key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "SI units")),
+	Sort:         key.NewBinding(key.WithKeys("ctrl+o"), key.WithHelp("ctrl+o", "sort")),
+	Profile:      key.NewBinding(key.WithKeys("ctrl+p"), key.WithHelp("ctrl+p", "profile")),
+	UpdateDB:     key.NewBinding(key.WithKeys("ctrl+u"), key.WithHelp("ctrl+u", "update db")),
+	Jobs:         key.NewBinding(key.WithKeys("alt+j"), key.WithHelp("alt+j", "jobs")),
+	Settings:     key.NewBinding(key.WithKeys("f2"), key.WithHelp("f2", "settings")),
+	About:        key.NewBinding(key.WithKeys("f3"), key.WithHelp("f3", "about")),
+	Help:         key.NewBinding(key.WithKeys("f1"), key.WithHelp("f1", "help")),
+	Quit:         key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+}
+
+// actionNames are the config file names of the rebindable actions, in settings screen order
+var actionNames = []string{"copy", "focus", "switch_pane", "mark", "checksum", "diff", "send", "share", "siblings", "pane", "elevate", "save_search", "searches", "history", "root", "live", "watch", "content", "ignore", "pipe", "batch", "permissions", "match_mode", "ignore_case", "basename", "compact", "clear", "units", "sort", "profile", "update_db", "jobs", "settings", "about", "help", "quit"}
+
+func (k *keyMap) action(name string) *key.Binding {
+	switch name {
+	case "copy":
+		return &k.Copy
+	case "focus":
+		return &k.Focus
+	case "switch_pane":
+		return &k.SwitchPane
+	case "mark":
+		return &k.Mark
+	case "checksum":
+		return &k.Checksum
+	case "diff":
+		return &k.Diff
+	case "send":
+		return &k.Send
+	case "share":
+		return &k.Share
+	case "siblings":
+		return &k.Siblings
+	case "pane":
+		return &k.Pane
+	case "elevate":
+		return &k.Elevate
+	case "save_search":
+		return &k.SaveSearch
+	case "searches":
+		return &k.Searches
+	case "history":
+		return &k.History
+	case "root":
+		return &k.Root
+	case "live":
+		return &k.Live
+	case "watch":
+		return &k.Watch
+	case "content":
+		return &k.Content
+	case "ignore":
+		return &k.Ignore
+	case "pipe":
+		return &k.Pipe
+	case "batch":
+		return &k.Batch
+	case "permissions":
+		return &k.Permissions
+	case "match_mode":
+		return &k.MatchMode
+	case "ignore_case":
+		return &k.IgnoreCase
+	case "basename":
+		return &k.Basename
+	case "compact":
+		return &k.Compact
+	case "clear":
+		return &k.Clear
+	case "units":
+		return &k.Units
+	case "sort":
+		return &k.Sort
+	case "profile":
+		return &k.Profile
+	case "update_db":
+		return &k.UpdateDB
+	case "settings":
+		return &k.Settings
+	case "jobs":
+		return &k.Jobs
+	case "about":
+		return &k.About
+	case "help":
+		return &k.Help
+	case "quit":
+		return &k.Quit
+	}
+	return nil
+}
+
+// withOverrides rebinds actions from the config, ignoring names it doesn't know
+func (k keyMap) withOverrides(overrides map[string][]string) keyMap {
+	for name, ks := range overrides {
+		if b := k.action(name); b != nil && len(ks) > 0 {
+			b.SetKeys(ks...)
+			b.SetHelp(strings.Join(ks, "/"), b.Help().Desc)
+		}
+	}
+	return k
+}
+
+// match names the action msg is bound to, or "" if it isn't a shortcut
+func (k keyMap) match(msg tea.KeyMsg) string {
+	for _, name := range actionNames {
+		if key.Matches(msg, *k.action(name)) {
+			return name
+		}
+	}
+	return ""
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Copy, k.Clear, k.Units, k.Sort, k.Profile, k.UpdateDB, k.Help, k.Quit}
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Copy, k.QuickSelect, k.Focus, k.SwitchPane, k.SetRegister, k.JumpRegister, k.Refine, k.Prefix, k.Mark, k.Checksum, k.Diff, k.Send, k.Share}, {k.Siblings, k.Pane, k.Elevate, k.SaveSearch, k.Searches, k.History, k.Root, k.Live, k.Watch, k.Content, k.Ignore, k.Pipe, k.Batch, k.Permissions, k.MatchMode, k.IgnoreCase, k.Basename, k.Compact, k.Clear, k.Units, k.Sort, k.Profile, k.UpdateDB, k.Jobs, k.Settings, k.About, k.Help, k.Quit}}
+}
+
+// refreshKeys only shows the hints that do something in the current state
+func (m *model) refreshKeys() {
+	if len(m.rows) > 0 {
+		m.keys.Copy.SetHelp(m.keys.Copy.Help().Key, "copy path")
+	} else {
+		m.keys.Copy.SetHelp(m.keys.Copy.Help().Key, "quit")
+	}
+	if m.tableFocused {
+		m.keys.Focus.SetHelp(m.keys.Focus.Help().Key, "back to search")
+	} else {
+		m.keys.Focus.SetHelp(m.keys.Focus.Help().Key, "results")
+	}
+	// registers are typed into the query otherwise, and only remember results, not pane entries
+	m.keys.SetRegister.SetEnabled(m.tableFocused && !m.pane.focused && len(m.rows) > 0)
+	m.keys.Refine.SetEnabled(m.tableFocused && len(m.results) > 0)
+	m.keys.JumpRegister.SetEnabled(m.tableFocused && !m.pane.focused && len(m.registers) > 0)
+	m.keys.Siblings.SetEnabled(len(m.rows) > 0)
+	m.keys.Elevate.SetEnabled(m.caps.pkexec && m.remote == "" && len(m.deniedPaths()) > 0)
+	m.keys.Pipe.SetEnabled(len(m.rows) > 0)
+	m.keys.Batch.SetEnabled(!m.batchRunning && (len(m.rows) > 0 || len(m.marked) > 0))
+	m.keys.Permissions.SetEnabled(len(m.rows) > 0 || len(m.marked) > 0)
+	m.keys.SaveSearch.SetEnabled(strings.TrimSpace(m.searchQuery) != "")
+	m.keys.SwitchPane.SetEnabled(m.tableFocused && m.paneShown())
+	m.keys.Mark.SetEnabled(len(m.rows) > 0 && !m.keys.SwitchPane.Enabled()) // tab moves between the panes instead
+	if m.pane.on {
+		m.keys.Pane.SetHelp(m.keys.Pane.Help().Key, "close folder pane")
+	} else {
+		m.keys.Pane.SetHelp(m.keys.Pane.Help().Key, "folder pane")
+	}
+	if len(m.marked) > 0 {
+		m.keys.Checksum.SetHelp(m.keys.Checksum.Help().Key, fmt.Sprintf("sha256 %d marked", len(m.marked)))
+	} else {
+		m.keys.Checksum.SetHelp(m.keys.Checksum.Help().Key, "sha256")
+	}
+	m.keys.Diff.SetEnabled(m.tableFocused && len(m.marked) == 2) // deletes the next word of the query otherwise
+	m.keys.Send.SetEnabled(len(m.cfg.SendCommand) > 0 && !m.sending && (len(m.rows) > 0 || len(m.marked) > 0))
+	m.keys.Share.SetEnabled(len(m.rows) > 0 || len(m.marked) > 0)
+	m.keys.Checksum.SetEnabled(m.tableFocused && (len(m.rows) > 0 || len(m.marked) > 0)) // deletes to the end of the query otherwise
+	m.keys.MatchMode.SetHelp(m.keys.MatchMode.Help().Key, matchModeNames[(m.matchMode+1)%matchModeCount])
+	m.keys.MatchMode.SetEnabled(m.caps.regex || m.live) // every mode but substring is a regex to locate
+	m.keys.Basename.SetEnabled(m.caps.basename || m.live)
+	switch backend.(type) {
+	case locateSearcher, databasesSearcher, indexSearcher, daemonSearcher:
+		m.keys.UpdateDB.SetEnabled(true)
+	default:
+		m.keys.UpdateDB.SetEnabled(false)
+	}
+	if m.content {
+		m.keys.Content.SetHelp(m.keys.Content.Help().Key, "name search")
+	} else {
+		m.keys.Content.SetHelp(m.keys.Content.Help().Key, "content search")
+	}
+	if m.live {
+		m.keys.Live.SetHelp(m.keys.Live.Help().Key, "locate db")
+	} else {
+		m.keys.Live.SetHelp(m.keys.Live.Help().Key, "live search")
+	}
+	if m.watch.on {
+		m.keys.Watch.SetHelp(m.keys.Watch.Help().Key, "stop watching")
+	} else {
+		m.keys.Watch.SetHelp(m.keys.Watch.Help().Key, "watch")
+	}
+	if m.ignoreCase {
+		m.keys.IgnoreCase.SetHelp(m.keys.IgnoreCase.Help().Key, "match case")
+	} else {
+		m.keys.IgnoreCase.SetHelp(m.keys.IgnoreCase.Help().Key, "ignore case")
+	}
+	if m.basename {
+		m.keys.Basename.SetHelp(m.keys.Basename.Help().Key, "whole paths")
+	} else {
+		m.keys.Basename.SetHelp(m.keys.Basename.Help().Key, "names only")
+	}
+	if m.compact {
+		m.keys.Compact.SetHelp(m.keys.Compact.Help().Key, "full rows")
+	} else {
+		m.keys.Compact.SetHelp(m.keys.Compact.Help().Key, "compact")
+	}
+	m.keys.Clear.SetEnabled(m.searchQuery != "" || len(m.rows) > 0)
+	m.keys.Sort.SetHelp(m.keys.Sort.Help().Key, "sort: "+m.sortName((m.sortMode+1)%sortModeCount))
+	if m.siUnit {
+		m.keys.Units.SetHelp(m.keys.Units.Help().Key, "binary units")
+	} else {
+		m.keys.Units.SetHelp(m.keys.Units.Help().Key, "SI units")
+	}
+}
@@ -1,20 +1,25 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"math"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"strconv"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
 )
 
 var baseStyle = lipgloss.NewStyle().
@@ -24,26 +29,205 @@ var baseStyle = lipgloss.NewStyle().
 type model struct {
 	table                              table.Model
 	textInput                          textinput.Model
+	help                               help.Model
+	keys                               keyMap
+	toasts                             toasts
+	modals                             modals
 	searchQuery, statusMessage, output string
 	siUnit                             bool
 	width, height                      int
 	itemLimit, visibleRows             int
 	lastItemLimit                      int
 	lastQuery                          string
+	cfg                                config
+	types                              fileTypes // the built-in file types and the config's
+	searchSeq                          int
+	shownQuery                         string // query the rows in the table came from
+	shownComplete                      bool   // whether those rows were every match, not just the first limit
+	cancelSearch                       context.CancelFunc
+	slowStreak                         int         // consecutive searches slower than slowQuery
+	results, rows                      []table.Row // rows in plocate's order, and in the order shown
+	sortMode                           sortMode
+	infos                              map[rowID]os.FileInfo // stat of each loaded result, once known
+	profile                            int                   // index into the config's profiles, -1 for none
+	home                               string
+	marked                             map[rowID]bool // rows picked with tab, by path
+	sending                            bool           // a send_command is running
+	batchRunning                       bool           // a command is running for each selected file
+	readOnly                           bool           // -read-only: refuse mutatingActions
+	remote                             string         // -remote: the user@host searched over ssh, "" for this machine
+	elevation                          elevation
+	jobs                               *jobManager          // stats, hashes and reindexing, shared by every copy of the model
+	indexWatch                         <-chan indexWatchMsg // changes the index watcher saw, nil unless watch_index is on
+	matchMode                          matchMode
+	ignoreCase                         bool // plocate -i
+	basename                           bool // plocate -b, match the last path component only
+	compact                            bool // icon and path only, nothing statted
+	jump                               rowJump
+	tableFocused                       bool // keys move through the results instead of editing the query
+	typeAhead                          typeAhead
+	register                           registerOp      // m or ' was pressed, waiting for the letter
+	registers                          map[rune]rowID  // rows saved with m<letter>
+	queryErr                           string          // why the last search failed, shown under the input
+	root                               string          // only show results under this directory, "" for everywhere
+	refine                             textinput.Model // narrows the loaded rows, opened with / in the table
+	prefix                             textinput.Model // keeps results to paths starting with it
+	queryHistory                       []string        // queries whose results were used, newest first
+	recalled                           int             // how far back up/down went in queryHistory, 0 for not
+	suggest                            suggestState
+	content                            bool // find files by what's in them with rg, rather than by name
+	matchLine                          matchLine
+	live                               bool // search the filesystem with fd rather than the locate database
+	watch                              watchState
+	pane                               paneState // the selected result's directory beside the results
+	caps                               capabilities
 }
 
 type searchResultsMsg struct {
-	query string
-	limit int
-	rows  []table.Row
-	err   error
+	query   string
+	limit   int
+	rows    []table.Row
+	infos   map[rowID]os.FileInfo // stats already taken while filtering
+	pending []string              // paths whose rows still need a stat
+	total   int                   // every match plocate printed, not just the rows loaded
+	elapsed time.Duration
+	err     error
+
+	approximate bool // nothing matched exactly, these match a relaxed query
 }
 
 type updateDBMsg struct {
 	err error
 }
 
+// debounceMsg fires once typing pauses; stale ones are told apart by seq
+type debounceMsg struct {
+	seq int
+}
+
 func main() {
+	about := flag.Bool("about", false, "print version and diagnostics, then exit")
+	readOnly := flag.Bool("read-only", false, "disable every action that writes files, e.g. on production servers")
+	root := flag.String("root", "", "only show results under `dir`, e.g. ~/projects")
+	fakeBackend := flag.String("fake-backend", "", "serve results from a JSON `fixture` instead of plocate and the filesystem, for tests and demos")
+	live := flag.Bool("live", false, "search the filesystem with fd instead of the locate database, for directories it doesn't cover or is out of date on")
+	verify := flag.String("verify", "", "check the files listed in a SHA256SUMS `manifest`, then exit")
+	remote := flag.String("remote", "", "search the locate database on `user@host` over ssh instead of this machine's")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags]\n       %s index [dir...]   build or update gocate's own index of each dir, or of every indexed one\n       %s daemon          keep the indexes in memory and answer searches from them\n", os.Args[0], os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	switch flag.Arg(0) {
+	case "index":
+		if err := runIndexCommand(flag.Args()[1:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "index:", err)
+			os.Exit(2)
+		}
+		return
+	case "daemon":
+		if err := runDaemon(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "daemon:", err)
+			os.Exit(2)
+		}
+		return
+	}
+	locate, found := detectSearcher()
+	backend = locate
+	if !found {
+		backend = walkSearcher{}
+	}
+	if fd, ok := detectFd(); ok {
+		liveBackend = fd
+	}
+	if rg, ok := detectRg(); ok {
+		contentBackend = rg
+	}
+	if *fakeBackend != "" {
+		fx, err := loadFixture(*fakeBackend)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		useFixture(fx)
+	}
+	if *remote != "" {
+		l, err := detectRemoteSearcher(*remote)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "-remote:", err)
+			os.Exit(2)
+		}
+		useRemote(l)
+		found = true
+	}
+	home, _ := os.UserHomeDir()
+	cfg, cfgErr := loadConfig()
+	if _, walking := backend.(walkSearcher); (walking || cfg.UseIndex && *remote == "" && *fakeBackend == "") && len(indexFiles()) > 0 {
+		backend, found = indexSearcher{}, true
+		if d, ok := detectDaemon(); ok {
+			backend = d
+		}
+	}
+	if l, ok := backend.(locateSearcher); ok && len(cfg.Databases) > 0 {
+		backend = withDatabases(l, cfg.Databases, home)
+	}
+	caps := probeCapabilities()
+	if *about {
+		fmt.Println(aboutText(caps))
+		return
+	}
+	if *verify != "" {
+		ok, err := verifyManifest(*verify, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	m := newModel(home)
+	m.readOnly, m.remote, m.caps, m.live = *readOnly, *remote, caps, *live && liveBackend != nil
+	if cfgErr != nil {
+		m.statusMessage = fmt.Sprintf("Failed to load config: %v", cfgErr)
+	}
+	switch {
+	case *live && *remote != "":
+		m.statusMessage = "Live search walks this machine, searching the locate database on " + *remote + " instead"
+	case !found && *fakeBackend == "":
+		m.statusMessage = "No plocate, mlocate or locate found in $PATH, walking the filesystem instead (slower)"
+	case *live && liveBackend == nil:
+		m.statusMessage = "Install fd for live search, searching the locate database instead"
+	case !caps.null && !m.live:
+		m.statusMessage = fmt.Sprintf("%s has no -0 option, upgrade it to search", caps.backendVersion)
+	}
+	m.applyConfig(cfg)
+	if _, ok := backend.(indexSearcher); ok && cfg.WatchIndex {
+		m.indexWatch = watchIndex(cfg.Ignore)
+	}
+	if *root != "" {
+		var err error
+		if m.root, err = resolveRoot(*root, home); err != nil {
+			fmt.Fprintln(os.Stderr, "-root:", err)
+			os.Exit(2)
+		}
+	}
+	zone.NewGlobal()
+	result, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion()).Run()
+	if err != nil {
+		fmt.Println("Error running program:", err)
+		os.Exit(1)
+	}
+
+	if fm, ok := result.(model); ok && fm.output != "" {
+		fmt.Println(fm.output) // if cannot copy to user's clipboard, exit and print the path
+	}
+}
+
+// newModel is the UI before the flags and the config are applied
+func newModel(home string) model {
 	t := table.New(
 		table.WithColumns([]table.Column{
 			{Title: "Filename", Width: 40},
@@ -53,177 +237,601 @@ func main() {
 		table.WithFocused(true),
 		table.WithHeight(30),
 	)
-	s := table.DefaultStyles()
-	s.Header = s.Header.BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).BorderBottom(true).Bold(false)
-	s.Selected = s.Selected.Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("#3e6589")).Bold(false)
-	t.SetStyles(s)
-
 	ti := textinput.New()
 	ti.Placeholder = "Search for anything..."
 	ti.Focus()
 	ti.CharLimit = 128
 	ti.Width = 30
 
-	m := model{table: t, textInput: ti, itemLimit: 30, visibleRows: 30}
-	result, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
-	if err != nil {
-		fmt.Println("Error running program:", err)
-		os.Exit(1)
-	}
-
-	if fm, ok := result.(model); ok && fm.output != "" {
-		fmt.Println(fm.output) // if cannot copy to user's clipboard, exit and print the path
-	}
+	return model{table: t, textInput: ti, help: help.New(), home: home, refine: newRefineInput(), prefix: newPrefixInput(), queryHistory: loadHistory("query"), profile: -1, itemLimit: 30, visibleRows: 30, jobs: newJobManager(), pane: paneState{table: newPaneTable()}}
 }
 
 func (m model) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, waitIndexWatch(m.indexWatch))
 }
 
-func (m model) View() string {
-	return baseStyle.Width(m.width-2).MaxWidth(m.width).Render(
-		m.textInput.View()+"\n\n"+m.table.View()+"\n\n"+m.statusMessage,
-	) + "\n"
+// status is the segments of the status line, with a count of marked rows if
+// there are any and the size on each device while sorting by device
+func (m model) status() []string {
+	var parts []string
+	if s := m.matchIndicator(); s != "" {
+		parts = append(parts, s)
+	}
+	if m.statusMessage != "" {
+		parts = append(parts, m.statusMessage)
+	}
+	if m.refine.Focused() || m.refine.Value() != "" {
+		parts = append(parts, fmt.Sprintf("%s (%d of %d)", m.refine.View(), len(m.rows), len(m.results)))
+	}
+	if m.root != "" {
+		parts = append(parts, "under "+m.displayRoot())
+	}
+	if m.remote != "" {
+		parts = append(parts, "on "+m.remote)
+	}
+	if s := m.elevationStatus(); s != "" {
+		parts = append(parts, s)
+	}
+	if s := m.matchLineStatus(); s != "" {
+		parts = append(parts, s)
+	}
+	if m.prefix.Focused() || m.prefix.Value() != "" {
+		parts = append(parts, m.prefix.View())
+	}
+	if s := m.registerStatus(); s != "" {
+		parts = append(parts, s)
+	}
+	if s := m.typeAheadStatus(); s != "" {
+		parts = append(parts, s)
+	}
+	if len(m.marked) > 0 {
+		parts = append(parts, fmt.Sprintf("%d marked", len(m.marked)))
+	}
+	if s := m.watchStatus(); s != "" {
+		parts = append(parts, s)
+	}
+	if f, n := m.jobs.overall(); n > 0 {
+		s := fmt.Sprintf("%d jobs", n)
+		if n == 1 {
+			s = "1 job"
+		}
+		if f >= 0 {
+			s = progressBar(f, 12) + " " + s
+		}
+		parts = append(parts, s)
+	}
+	if m.jump.active {
+		parts = append(parts, "go to row :"+m.jump.digits)
+	}
+	if m.sortMode == sortDevice {
+		if s := m.deviceSummary(); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	if m.sortMode == sortType {
+		if s := m.typeSummary(); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return parts
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
+	handled := false // an action used the key or click, so the input and table don't get it
+	skipTable := false
+
+	if m.modals.active() { // dialogs swallow input so nothing behind them reacts
+		switch msg := msg.(type) {
+		case tea.KeyMsg, tea.MouseMsg, closeDialogMsg:
+			if k, ok := msg.(tea.KeyMsg); ok && key.Matches(k, m.keys.Quit) {
+				return m, tea.Quit
+			}
+			var cmd tea.Cmd
+			m.modals, cmd = m.modals.update(msg)
+			return m, cmd
+		}
+	}
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg: // handle resizing
 		m.width, m.height = msg.Width, msg.Height
-		tableHeight := max(m.height-8, 1)
-		m.table.SetHeight(tableHeight)
-		m.visibleRows = tableHeight
+		m.resizeTable()
+		m.resizeColumns()
 
 		contentWidth := m.width - 2
-		available := max(contentWidth-5*2-32, 20)
-		nameWidth := available * 30 / 100
-		m.table.SetColumns([]table.Column{
-			{Title: "", Width: 2},
-			{Title: "Filename", Width: nameWidth},
-			{Title: "Path", Width: max(available-nameWidth, 10)},
-			{Title: "Size", Width: 10},
-			{Title: "Modified Time", Width: 20},
-		})
 		m.textInput.Width = contentWidth - 2
+		m.help.Width = contentWidth
 
 	case tea.KeyMsg: // handle keyboard input
-		switch msg.String() {
-		case "ctrl+c":
-			return m, tea.Quit
-		case "ctrl+s":
-			m.siUnit = !m.siUnit
-			m.lastQuery = ""
-		case "ctrl+u":
-			c := exec.Command("bash", "-c", updatedbCommand)
-			return m, tea.ExecProcess(c, func(err error) tea.Msg {
-				return updateDBMsg{err}
-			})
-		case "enter":
-			if row := m.table.SelectedRow(); row != nil {
-				err := clipboard.WriteAll(row[2])
-				if err != nil { // if user doesn't have wl-clipboard, xsel or xclip
-					m.output = row[2]
-				}
+		if ok, cmd := m.jumpKey(msg); ok {
+			return m, cmd
+		}
+		if ok, cmd := m.refineKey(msg); ok {
+			return m, cmd
+		}
+		if ok, cmd := m.prefixKey(msg); ok {
+			handled, skipTable = true, true
+			cmds = append(cmds, cmd)
+			break
+		}
+		if m.recallKey(msg) {
+			handled, skipTable = true, true
+			break
+		}
+		if ok, cmd := m.suggestKey(msg); ok {
+			handled, skipTable = true, true
+			cmds = append(cmds, cmd)
+			break
+		}
+		if ok, cmd := m.registerKey(msg); ok {
+			return m, cmd
+		}
+		if key.Matches(msg, m.keys.QuickSelect) { // never reaches the input, alt+digit isn't text
+			return m, m.quickSelect(int(msg.Runes[0] - '1'))
+		}
+		if path, ok := pastedPath(string(msg.Runes), m.home); msg.Paste && ok {
+			m.modals = m.modals.open(pasteDialog{path}) // the paste still lands in the input below
+		}
+		if action := m.keys.match(msg); action != "" {
+			handled = true
+			cmds = append(cmds, m.do(action))
+		} else if msg.Type == tea.KeyRunes && !msg.Alt && !msg.Paste {
+			switch {
+			case m.pane.focused: // j/k/g/G move through the folder
+			case m.tableFocused:
+				handled = true
+				cmds = append(cmds, m.typeAheadKey(msg))
+			default:
+				skipTable = true // letters are for the query, not the table's j/k/g/G
 			}
-			return m, tea.Quit
-		case "alt+ctrl+h": // need to test this on more systems (seems to be ctrl+alt+backspace on mine?)
-			m.textInput.SetValue("")
-			m.searchQuery = ""
-			m.table.SetRows([]table.Row{})
+		}
+
+	case tea.MouseMsg:
+		if action := clickedButton(msg); action != "" {
+			handled = true
+			cmds = append(cmds, m.do(action))
 		}
 
 	case updateDBMsg:
+		forgetIndexNames()
 		if msg.err != nil {
-			m.statusMessage = fmt.Sprintf("Failed to update DB: %v", msg.err)
+			cmds = append(cmds, notify(toastError, fmt.Sprintf("Failed to update DB: %v", msg.err)))
 		} else {
-			m.statusMessage = "Updated DB!"
+			cmds = append(cmds, notify(toastInfo, "Updated DB!"))
+		}
+
+	case aboutMsg:
+		m.modals = m.modals.open(textDialog{"About gocate", msg.text})
+
+	case configChangedMsg:
+		m.applyConfig(msg.cfg)
+		if err := saveConfig(msg.cfg); err != nil {
+			cmds = append(cmds, notify(toastError, fmt.Sprintf("Failed to save config: %v", err)))
+		}
+
+	case debounceMsg:
+		if msg.seq == m.searchSeq && m.searchQuery != "" {
+			cmds = append(cmds, m.search())
+		}
+
+	case matchLineMsg:
+		if msg.id == m.matchLine.id {
+			m.matchLine = matchLine(msg)
+		}
+
+	case rowStatMsg:
+		switch {
+		case errors.Is(msg.err, fs.ErrPermission): // there, but in a directory this user can't read
+			cmds = append(cmds, m.deny(msg.id))
+		case msg.err != nil: // gone since the database was last updated
+			m.removeRow(msg.id)
+		default:
+			if m.infos != nil {
+				m.infos[msg.id] = msg.info
+			}
+			m.updateRow(msg.id, func(row table.Row) { copy(row, msg.row) })
+		}
+
+	case indexWatchMsg:
+		cmds = append(cmds, waitIndexWatch(msg.next))
+		if msg.err != nil {
+			cmds = append(cmds, notify(toastWarn, "Watching the index: "+msg.err.Error()))
+		}
+		if m.searchQuery != "" { // same query, so the selection stays
+			cmds = append(cmds, m.search())
 		}
 
+	case watchTickMsg:
+		if msg.seq == m.watch.seq && m.watch.on {
+			cmds = append(cmds, m.rerunWatched(msg.seq), m.watchTick())
+		}
+
+	case watchEventMsg:
+		if msg.seq == m.watch.seq && m.watch.on {
+			cmds = append(cmds, m.rerunWatched(msg.seq), waitWatchEvent(msg.seq, msg.events))
+		}
+
+	case jobCancelledMsg:
+		if msg.kind != jobStat { // those only go with the rows they were for
+			m.statusMessage = ""
+			cmds = append(cmds, notify(toastInfo, "Cancelled "+msg.label))
+		}
+
+	case jobsProgressMsg:
+		cmds = append(cmds, m.jobs.nextProgress())
+
+	case jobsTickMsg:
+		var cmd tea.Cmd
+		m.modals, cmd = m.modals.broadcast(msg)
+		cmds = append(cmds, cmd)
+
+	case elevatedStatMsg:
+		cmds = append(cmds, m.applyElevated(msg))
+
+	case toastMsg:
+		var cmd tea.Cmd
+		m.toasts, cmd = m.toasts.push(msg)
+		cmds = append(cmds, cmd)
+
+	case typeAheadExpiredMsg:
+		if msg.seq == m.typeAhead.seq {
+			m.typeAhead = typeAhead{seq: m.typeAhead.seq}
+		}
+
+	case toastExpiredMsg:
+		m.toasts = m.toasts.expire(msg.id)
+
+	case checksumDirMsg:
+		dir, err := manifestDir(msg.dir, m.home)
+		if err != nil {
+			return m, notify(toastError, "SHA-256: "+err.Error())
+		}
+		m.statusMessage = fmt.Sprintf("Hashing %d files…", len(msg.paths))
+		logged := auditEntry{Action: "checksum", Paths: msg.paths, Target: dir}
+		return m, tea.Batch(m.jobs.runAudited(jobHash, fmt.Sprintf("sha256 of %d files", len(msg.paths)), logged, func(ctx context.Context, e *auditEntry) (tea.Msg, error) {
+			sums := checksumMarked(ctx, msg.paths, dir)
+			if sums.manifest != "" {
+				e.Target = sums.manifest
+			}
+			return sums, sums.err
+		}), m.jobs.watchProgress())
+
+	case checksumsMsg:
+		m.statusMessage = ""
+		m.modals = m.modals.open(textDialog{"SHA-256", checksumsText(msg)})
+		return m, nil
+
+	case diffMsg:
+		m.statusMessage = ""
+		if msg.err != nil {
+			return m, notify(toastError, msg.err.Error())
+		}
+		m.modals = m.modals.open(newPagerDialog("Diff", colorDiff(msg.text), m.width, m.height))
+		return m, nil
+
+	case transferMsg:
+		if !msg.done {
+			m.statusMessage = transferStatus(msg.line)
+			return m, waitTransfer(msg.next)
+		}
+		m.sending = false
+		m.statusMessage = ""
+		m.refreshKeys()
+		if errors.Is(msg.err, context.Canceled) {
+			return m, notify(toastInfo, "Cancelled send")
+		} else if msg.err != nil {
+			return m, notify(toastError, "Send failed: "+msg.err.Error())
+		}
+		return m, notify(toastInfo, "Sent")
+
+	case shareMsg:
+		if msg.err != nil && msg.done != "" { // copied some of them
+			return m, notify(toastWarn, msg.done+": "+msg.err.Error())
+		} else if msg.err != nil {
+			return m, notify(toastError, "Share failed: "+msg.err.Error())
+		}
+		return m, notify(toastInfo, msg.done)
+
+	case setRootMsg:
+		cmds = append(cmds, m.setRoot(msg.dir))
+
+	case saveSearchMsg:
+		return m, m.saveSearch(msg.search)
+
+	case runSavedSearchMsg:
+		cmds = append(cmds, m.runSavedSearch(msg.search))
+
+	case pickHistoryMsg:
+		m.setTableFocus(false)
+		m.textInput.SetValue(msg.query)
+		m.textInput.CursorEnd()
+		m.suggest = suggestState{query: msg.query, cursor: -1, hidden: true}
+
+	case forgetQueryMsg:
+		m.queryHistory = slices.DeleteFunc(slices.Clone(m.queryHistory), func(q string) bool { return q == msg.query })
+		if err := forgetHistory("query", msg.query); err != nil {
+			cmds = append(cmds, notify(toastWarn, "Couldn't save the query history: "+err.Error()))
+		}
+
+	case deleteSearchMsg:
+		cfg := m.cfg
+		cfg.Searches = msg.searches
+		return m, func() tea.Msg { return configChangedMsg{cfg} }
+
+	case pipeCommandMsg:
+		if msg.command == "" {
+			return m, nil
+		}
+		if err := saveHistory("pipe", msg.command); err != nil {
+			cmds = append(cmds, notify(toastWarn, "Couldn't save the pipe history: "+err.Error()))
+		}
+		m.statusMessage = "Running " + msg.command + "…"
+		cmds = append(cmds, m.pipeResults(msg.command))
+
+	case pipeMsg:
+		m.statusMessage = ""
+		m.modals = m.modals.open(newPagerDialog(fmt.Sprintf("%d results | %s", msg.count, msg.command), pipeOutput(msg), m.width, m.height))
+		return m, nil
+
+	case batchCommandMsg:
+		if msg.template == "" || m.batchRunning {
+			return m, nil
+		}
+		if err := saveHistory("batch", msg.template); err != nil {
+			cmds = append(cmds, notify(toastWarn, "Couldn't save the batch history: "+err.Error()))
+		}
+		m.batchRunning = true
+		m.modals = m.modals.open(newBatchDialog(msg.template, msg.paths, m.width, m.height))
+		cmds = append(cmds, runBatch(msg.template, msg.paths))
+
+	case batchMsg:
+		var cmd tea.Cmd
+		m.modals, cmd = m.modals.broadcast(msg)
+		if !msg.done {
+			return m, tea.Batch(cmd, waitBatch(msg.next))
+		}
+		m.batchRunning = false
+		m.refreshKeys()
+		if msg.failed > 0 {
+			return m, tea.Batch(cmd, notify(toastWarn, "Batch: "+batchSummary(msg)))
+		}
+		return m, tea.Batch(cmd, notify(toastInfo, "Batch: "+batchSummary(msg)))
+
+	case permsSpecMsg:
+		if msg.spec == "" {
+			return m, nil
+		}
+		if err := saveHistory("permissions", msg.spec); err != nil {
+			cmds = append(cmds, notify(toastWarn, "Couldn't save the permissions history: "+err.Error()))
+		}
+		cmds = append(cmds, m.planPermsJob(msg.spec, msg.paths))
+
+	case permsPlanMsg:
+		if msg.err != nil {
+			if errors.Is(msg.err, context.Canceled) {
+				return m, nil
+			}
+			return m, notify(toastError, "chmod/chown: "+msg.err.Error())
+		}
+		m.modals = m.modals.open(newPermsDialog(msg, m.width, m.height))
+		return m, nil
+
+	case permsApplyMsg:
+		return m, m.applyPermsJob(msg)
+
+	case permsDoneMsg:
+		if err := msg.failure(); err != nil {
+			m.modals = m.modals.open(newPagerDialog(fmt.Sprintf("chmod/chown %s: %d changed, %d failed", msg.spec, msg.changed, len(msg.failed)), permsReport(msg), m.width, m.height))
+			return m, notify(toastWarn, fmt.Sprintf("chmod/chown: %d changed, %v", msg.changed, err))
+		}
+		return m, notify(toastInfo, fmt.Sprintf("chmod/chown: %d changed", msg.changed))
+
+	case paneListingMsg:
+		m.showListing(msg)
+		return m, nil
+
+	case jumpToPathMsg:
+		m.jumpTo(msg.path)
+		m.refreshKeys()
+		return m, nil
+
 	case searchResultsMsg:
 		if msg.query == m.searchQuery {
-			if msg.err != nil {
-				m.statusMessage = msg.err.Error()
+			m.queryErr = ""
+			if msg.err != nil { // keep the last good rows rather than emptying the table
+				m.queryErr = firstLine(msg.err.Error())
+				m.watch.rerun = "" // the next tick tries again
 			} else {
-				m.table.SetRows(msg.rows)
-				m.statusMessage = fmt.Sprintf("Limit %d results", len(msg.rows))
+				sameQuery := msg.query == m.shownQuery
+				m.infos = msg.infos
+				m.noteWatched(msg.query, msg.rows, sameQuery)
+				m.setResults(msg.rows)
+				if !sameQuery { // only more rows of the same search keep the selection
+					m.table.SetCursor(0)
+				}
+				if !sameQuery { // the rows still waiting for a stat are gone
+					m.jobs.cancelQueued(jobStat)
+				}
+				cmds = append(cmds, statRows(m.jobs, msg.pending, m.siUnit, m.types))
+				m.shownQuery, m.shownComplete = msg.query, msg.total <= msg.limit
+				m.statusMessage = fmt.Sprintf("Showing %d of %d results in %s", len(msg.rows), msg.total, formatElapsed(msg.elapsed))
+				if msg.approximate {
+					m.statusMessage = fmt.Sprintf("No exact matches, showing %d of %d approximate matches in %s", len(msg.rows), msg.total, formatElapsed(msg.elapsed))
+				}
 			}
+			cmds = append(cmds, m.trackLatency(msg.elapsed))
 		}
 	}
 
 	var cmd tea.Cmd
-	m.textInput, cmd = m.textInput.Update(msg)
-	cmds = append(cmds, cmd)
+	if !handled {
+		m.textInput, cmd = m.textInput.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 
 	m.searchQuery = m.textInput.Value()
+	if m.searchQuery != m.suggest.query {
+		m.suggest = suggestState{query: m.searchQuery, cursor: -1}
+	}
 
 	if m.table.Cursor() == m.itemLimit-1 {
 		m.itemLimit = m.table.Cursor() + m.visibleRows
 	}
 
 	if m.searchQuery != m.lastQuery {
+		m.jobs.typing()
+		m.clearRefine() // it was narrowing the old query's rows
 		m.itemLimit = m.visibleRows
 		m.table.SetCursor(0)
 	}
 
 	if m.searchQuery != "" && (m.itemLimit != m.lastItemLimit || m.searchQuery != m.lastQuery) {
+		typed := m.searchQuery != m.lastQuery
 		m.lastQuery = m.searchQuery
 		m.lastItemLimit = m.itemLimit
-		cmds = append(cmds, runSearch(m.searchQuery, m.itemLimit, m.siUnit))
+		if typed && m.cfg.DebounceMs > 0 { // loading more rows on scroll stays immediate
+			m.searchSeq++
+			seq := m.searchSeq
+			cmds = append(cmds, tea.Tick(time.Duration(m.cfg.DebounceMs)*time.Millisecond, func(time.Time) tea.Msg {
+				return debounceMsg{seq}
+			}))
+			m.narrowRows()
+		} else {
+			cmds = append(cmds, m.search())
+		}
 	}
 
-	m.table, cmd = m.table.Update(msg)
-	cmds = append(cmds, cmd)
+	if !handled && !skipTable {
+		if m.pane.focused {
+			m.pane.table, cmd = m.pane.table.Update(msg)
+		} else {
+			m.table, cmd = m.table.Update(msg)
+		}
+		cmds = append(cmds, cmd)
+	}
+	cmds = append(cmds, m.lookupMatchLine(), m.followSelection())
+	m.refreshKeys()
 	return m, tea.Batch(cmds...)
 }
 
-func runSearch(query string, limit int, siUnit bool) tea.Cmd {
-	return func() tea.Msg {
-		cmd := exec.Command("plocate", "-l", strconv.Itoa(limit), query)
-		var stdout, stderr bytes.Buffer
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if err := cmd.Run(); err != nil {
-			if stderr.Len() > 0 {
-				return searchResultsMsg{query: query, limit: limit, err: fmt.Errorf("%s", stderr.String())}
-			}
-			return searchResultsMsg{query: query, limit: limit, rows: []table.Row{}}
+// narrowRows filters the rows already shown by the query being typed so the table and
+// count keep up while the debounced search is pending. Only valid when the new query
+// extends the old one, since every match of "abc" is also a match of "ab".
+func (m *model) narrowRows() {
+	q, err := parseQuery(m.searchQuery, m.types)
+	if err != nil || len(q.filters) > 0 || q.depth >= 0 || len(q.alts) != 1 || len(q.excludes) > 0 || !m.canNarrow() || m.shownQuery == "" || !strings.Contains(m.searchQuery, m.shownQuery) {
+		return // clauses aren't checked here, only plain substring queries narrow
+	}
+	var rows []table.Row
+	for _, row := range m.results {
+		if m.matchesLocally(row[2], q.alts[0]) {
+			rows = append(rows, row)
 		}
+	}
+	m.setResults(rows)
+	if m.shownComplete {
+		m.statusMessage = fmt.Sprintf("%d results (updating…)", len(rows))
+	} else {
+		m.statusMessage = fmt.Sprintf("≥%d results (updating…)", len(rows))
+	}
+}
 
-		var rows []table.Row
-		for _, item := range strings.Split(stdout.String(), "\n") {
-			if item == "" {
-				continue
-			}
-			icon, size, mod := "📄", "", ""
-			info, err := os.Stat(item)
-			if err != nil {
-				continue
-			}
-			if info.IsDir() {
-				icon = "📂"
-			} else {
-				if info.Mode().Perm()&0111 != 0 {
-					icon = "🔧"
-				}
-				size = formatSize(info.Size(), siUnit)
-				mod = info.ModTime().Format("2006-01-02 15:04:05")
-			}
-			switch filepath.Ext(item) {
-			case ".zip", ".gz", ".7z":
-				icon = "📦"
-			case ".png", ".jpg", ".webp", ".jpeg":
-				icon = "🎨"
-			case ".mp4", ".mov":
-				icon = "📹"
-			}
-			rows = append(rows, table.Row{icon, filepath.Base(item), item, size, mod})
+// applyConfig makes cfg take effect immediately, without a restart
+func (m *model) applyConfig(cfg config) {
+	if cfg.SIUnits != m.cfg.SIUnits {
+		m.siUnit = cfg.SIUnits
+		m.lastQuery = "" // re-run the search so sizes are reformatted
+	}
+	if !slices.Equal(cfg.Ignore, m.cfg.Ignore) {
+		m.lastQuery = "" // search again without what's ignored now
+	}
+	if cfg.Order != m.cfg.Order {
+		m.lastQuery = "" // a different first page
+	}
+	types, err := mergeFileTypes(cfg.FileTypes)
+	if err != nil {
+		m.statusMessage = err.Error()
+	}
+	if !reflect.DeepEqual(types, m.types) {
+		m.types = types
+		m.lastQuery = "" // type: clauses and icons change with them
+	}
+	m.cfg = cfg
+	m.keys = keys.withOverrides(cfg.Keys)
+	styles := densityStyles(applyTheme(cfg.Theme), cfg.Density)
+	m.table.SetStyles(styles)
+	m.pane.table.SetStyles(styles)
+	if m.height > 0 { // sized once the window size is known
+		m.resizeTable()
+	}
+	m.resizeColumns()
+	m.refreshKeys()
+}
+
+// resizeColumns shares the window width out between the columns; hidden ones get width 0, which the table skips
+func (m *model) resizeColumns() {
+	sizeWidth, modWidth, visible := 0, 0, 3
+	showSize, showModified := m.cfg.ShowSize, m.cfg.ShowModified
+	if p := m.activeProfile(); p != nil {
+		if p.ShowSize != nil {
+			showSize = *p.ShowSize
 		}
-		return searchResultsMsg{query: query, limit: limit, rows: rows}
+		if p.ShowModified != nil {
+			showModified = *p.ShowModified
+		}
+	}
+	if showSize && !m.compact {
+		sizeWidth = 10
+		visible++
+	}
+	if showModified && !m.compact {
+		modWidth = 20
+		visible++
+	}
+	dbWidth := 0
+	if _, merged := backend.(databasesSearcher); merged && m.cfg.ShowDatabase && !m.compact {
+		dbWidth = 24
+		visible++
+	}
+	if m.compact { // the path already ends in the name
+		visible--
+	}
+	iconWidth := 2
+	if m.compact { // rows are numbered for :<n> jumps
+		iconWidth += numberWidth(len(m.results)) + 1
+	}
+	spare := func() int {
+		return m.resultsWidth() - visible*cellPadding(m.cfg.Density) - iconWidth - sizeWidth - modWidth - dbWidth
+	}
+	for _, w := range []*int{&dbWidth, &modWidth, &sizeWidth} { // rather than wrap every row, leave out what's least needed
+		if spare() >= 20 {
+			break
+		}
+		if *w > 0 {
+			*w = 0
+			visible--
+		}
+	}
+	available := max(spare(), 20)
+	nameWidth := available * 30 / 100
+	if m.compact {
+		nameWidth = 0
+	}
+	pathTitle := "Path"
+	if m.remote != "" {
+		pathTitle = "Path on " + m.remote
 	}
+	m.table.SetColumns([]table.Column{
+		{Title: "", Width: iconWidth},
+		{Title: "Filename", Width: nameWidth},
+		{Title: pathTitle, Width: max(available-nameWidth, 10)},
+		{Title: "Size", Width: sizeWidth},
+		{Title: "Modified Time", Width: modWidth},
+		{Title: "Database", Width: dbWidth},
+	})
+	m.refreshRows() // cells are truncated to the new widths
+	m.resizePane()
 }
 
 func formatSize(b int64, si bool) string {
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// matchMode is how plocate reads the pattern
+type matchMode int
+
+const (
+	matchSubstring  matchMode = iota // plocate's default
+	matchRegex                       // POSIX extended, --regex
+	matchBasicRegex                  // POSIX basic, --regexp
+	matchGlob                        // shell-style, translated to an extended regex
+	matchFuzzy                       // letters in order with gaps, ranked by how well they match
+	matchInitials                    // the starts of camelCase or snake_case words in the file name
+	matchModeCount
+)
+
+var matchModeNames = map[matchMode]string{
+	matchSubstring:  "substring",
+	matchRegex:      "regex",
+	matchBasicRegex: "basic regex",
+	matchGlob:       "glob",
+	matchFuzzy:      "fuzzy",
+	matchInitials:   "initials",
+}
+
+// searchOpts is how the current match settings have patterns matched
+func (m model) searchOpts() searchOpts {
+	return searchOpts{
+		regex:      m.matchMode == matchRegex || m.matchMode == matchGlob || m.matchMode == matchFuzzy || m.matchMode == matchInitials,
+		basicRegex: m.matchMode == matchBasicRegex,
+		ignoreCase: m.foldCase(),
+		basename:   m.basename,
+		root:       m.root,
+		maxDepth:   m.cfg.WalkDepth,
+		ignore:     m.cfg.Ignore,
+	}
+}
+
+// foldCase is whether matching ignores case: fuzzy matching always does, like
+// fzf for lowercase queries, and so does matching initials, where the case of
+// the name marks the words instead
+func (m model) foldCase() bool {
+	return m.ignoreCase || m.matchMode == matchFuzzy || m.matchMode == matchInitials
+}
+
+// plocatePatterns turns typed terms into what plocate is given, which only differs for globs, fuzzy terms and initials
+func (m model) plocatePatterns(terms []string) []string {
+	translate := map[matchMode]func(string) string{matchGlob: globToRegex, matchFuzzy: fuzzyRegex, matchInitials: initialsRegex}[m.matchMode]
+	if translate == nil {
+		return terms
+	}
+	patterns := make([]string, len(terms))
+	for i, t := range terms {
+		patterns[i] = translate(t)
+	}
+	return patterns
+}
+
+// globToRegex translates a shell glob into an extended regex matching whole
+// path components: * and ? stay within one, ** crosses any number of them,
+// and a glob without a slash matches the file name
+func globToRegex(glob string) string {
+	var b strings.Builder
+	b.WriteString("(^|/)")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// checkPattern catches a bad extended regex before plocate runs, so the error
+// shows while typing. Basic regexes are left to plocate, Go has no parser for them.
+func (m model) checkPattern(pattern string) error {
+	if m.matchMode == matchSubstring || m.matchMode == matchBasicRegex {
+		return nil
+	}
+	_, err := regexp.CompilePOSIX(pattern)
+	return err
+}
+
+// matchIndicator names the match settings for the status bar
+func (m model) matchIndicator() string {
+	on := []string{matchModeNames[m.matchMode]}
+	if m.ignoreCase {
+		on = append(on, "ignore case")
+	}
+	if m.basename {
+		on = append(on, "names only")
+	}
+	if m.live {
+		on = append(on, "live")
+	}
+	if m.content {
+		on = append(on, "content")
+	}
+	return "[" + strings.Join(on, ", ") + "]"
+}
+
+// canNarrow is whether matchesLocally agrees with plocate under the current settings
+func (m model) canNarrow() bool {
+	return m.matchMode == matchSubstring && !m.content
+}
+
+// subject is the part of path plocate matches against, case-folded with -i
+func (m model) subject(path string) string {
+	if m.basename {
+		path = filepath.Base(path)
+	}
+	if m.foldCase() {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// matchesLocally checks a path against every pattern the way plocate would, for narrowing shown rows
+func (m model) matchesLocally(path string, patterns []string) bool {
+	path = m.subject(path)
+	for _, p := range patterns {
+		if m.foldCase() {
+			p = strings.ToLower(p)
+		}
+		if !strings.Contains(path, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// excludeFilter drops paths matching any of terms, judged the way plocate would
+// match them with the current settings. Fuzzy and initials excludes are plain
+// substrings, since almost everything loosely matches a short word.
+func (m model) excludeFilter(terms []string) (filter, error) {
+	var excluded []func(path string) bool
+	for _, t := range terms {
+		switch m.matchMode {
+		case matchBasicRegex:
+			return filter{}, fmt.Errorf("!%s: excluding needs substring or extended regex matching", t)
+		case matchRegex, matchGlob:
+			re, err := compileRegex(m.plocatePatterns([]string{t})[0], m.ignoreCase)
+			if err != nil {
+				return filter{}, err
+			}
+			excluded = append(excluded, func(path string) bool {
+				if m.basename {
+					path = filepath.Base(path)
+				}
+				return re.MatchString(path)
+			})
+		default:
+			excluded = append(excluded, func(path string) bool { return m.matchesLocally(path, []string{t}) })
+		}
+	}
+	return filter{keep: func(path string, _ os.FileInfo) bool {
+		return !slices.ContainsFunc(excluded, func(match func(string) bool) bool { return match(path) })
+	}}, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGlobToRegex(t *testing.T) {
+	tests := []struct {
+		glob string
+		want string
+	}{
+		{"*.go", `(^|/)[^/]*\.go$`},
+		{"file?.txt", `(^|/)file[^/]\.txt$`},
+		{"[abc].md", `(^|/)[abc]\.md$`},
+		{"[!0-9]x", `(^|/)[^0-9]x$`},
+		{"[oops", `(^|/)\[oops$`},
+		{"src/**/*.c", `(^|/)src/(.*/)?[^/]*\.c$`},
+		{"logs/**", `(^|/)logs/.*$`},
+		{"a+b(1){2}|^$", `(^|/)a\+b\(1\)\{2\}\|\^\$$`},
+		{`back\slash`, `(^|/)back\\slash$`},
+	}
+	for _, tt := range tests {
+		if got := globToRegex(tt.glob); got != tt.want {
+			t.Errorf("globToRegex(%q) = %q, want %q", tt.glob, got, tt.want)
+		}
+	}
+}
+
+func TestGlobToRegexMatches(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"*.go", "/home/ann/src/main.go", true},
+		{"*.go", "/home/ann/src/main.go.orig", false}, // anchored at the end
+		{"*.go", "/home/ann/go/notes", false},
+		{"main.go", "/home/ann/src/main.go", true},
+		{"main.go", "/home/ann/src/domain.go", false}, // anchored at a component start
+		{"main.go", "/home/ann/src/mainXgo", false},   // the dot isn't any character
+		{"file?.txt", "/tmp/file1.txt", true},
+		{"file?.txt", "/tmp/file12.txt", false},
+		{"file?.txt", "/tmp/file/.txt", false}, // ? stays within a component
+		{"*.txt", "/tmp/a/b.txt", true},
+		{"a*.txt", "/tmp/a/b.txt", false}, // * stays within a component
+		{"[abc].md", "/docs/b.md", true},
+		{"[abc].md", "/docs/d.md", false},
+		{"[!0-9]x", "/docs/ax", true},
+		{"[!0-9]x", "/docs/5x", false},
+		{"[oops", "/tmp/[oops", true},
+		{"src/**/*.c", "/home/ann/src/main.c", true},
+		{"src/**/*.c", "/home/ann/src/lib/deep/util.c", true},
+		{"src/**/*.c", "/home/ann/mysrc/main.c", false},
+		{"logs/**", "/var/logs/2024/app.log", true},
+		{"a+b(1)", "/x/a+b(1)", true},
+		{"a+b(1)", "/x/aab1", false},
+	}
+	for _, tt := range tests {
+		re, err := regexp.CompilePOSIX(globToRegex(tt.glob))
+		if err != nil {
+			t.Errorf("globToRegex(%q) = %q doesn't compile: %v", tt.glob, globToRegex(tt.glob), err)
+			continue
+		}
+		if got := re.MatchString(tt.path); got != tt.match {
+			t.Errorf("glob %q matching %q = %v, want %v", tt.glob, tt.path, got, tt.match)
+		}
+	}
+}
+
+func TestExcludeFilter(t *testing.T) {
+	tests := []struct {
+		m     model
+		term  string
+		path  string
+		keeps bool
+	}{
+		{model{}, "tmp", "/x/a.tmp", false},
+		{model{}, "TMP", "/x/a.tmp", true},
+		{model{ignoreCase: true}, "TMP", "/x/a.tmp", false},
+		{model{matchMode: matchGlob}, "*.tmp", "/x/a.tmp", false},
+		{model{matchMode: matchGlob}, "*.TMP", "/x/a.tmp", true},
+		{model{matchMode: matchGlob, ignoreCase: true}, "*.TMP", "/x/a.tmp", false},
+		{model{matchMode: matchRegex}, `^/x/\S+$`, "/x/a.tmp", false},
+		{model{matchMode: matchRegex, ignoreCase: true}, `^/x/\S+$`, "/x/A.tmp", false},
+		{model{matchMode: matchRegex, ignoreCase: true}, `^/x/\S+$`, "/x/my a.tmp", true},
+		{model{matchMode: matchRegex, basename: true}, `^a\.`, "/x/a.tmp", false},
+		{model{matchMode: matchRegex, basename: true}, `^x`, "/x/a.tmp", true},
+	}
+	for _, tt := range tests {
+		f, err := tt.m.excludeFilter([]string{tt.term})
+		if err != nil {
+			t.Errorf("excluding %q: %v", tt.term, err)
+			continue
+		}
+		if got := f.keep(tt.path, nil); got != tt.keeps {
+			t.Errorf("!%s (%s) keeping %q = %v, want %v", tt.term, tt.m.matchIndicator(), tt.path, got, tt.keeps)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+var dialogStyle = lipgloss.NewStyle().
+	BorderStyle(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("#3e6589")).
+	Padding(0, 1)
+
+var dialogTitleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3e6589"))
+
+var closeDialog = key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "close"))
+
+// dialog is anything that can be stacked above the results
+type dialog interface {
+	Update(tea.Msg) (dialog, tea.Cmd)
+	View() string
+}
+
+// closeDialogMsg pops the top dialog, for dialogs that finish on their own
+type closeDialogMsg struct{}
+
+func closeTopDialog() tea.Msg { return closeDialogMsg{} }
+
+// modals is the dialog stack; while it isn't empty it owns all key and mouse input
+type modals struct {
+	stack []dialog
+}
+
+func (s modals) active() bool {
+	return len(s.stack) > 0
+}
+
+func (s modals) open(d dialog) modals {
+	s.stack = append(s.stack[:len(s.stack):len(s.stack)], d)
+	return s
+}
+
+func (s modals) close() modals {
+	if len(s.stack) > 0 {
+		s.stack = s.stack[:len(s.stack)-1]
+	}
+	return s
+}
+
+func dialogZone(i int) string {
+	return "dialog-" + strconv.Itoa(i)
+}
+
+// update routes input to the top dialog only; esc or a click outside it dismisses it
+func (s modals) update(msg tea.Msg) (modals, tea.Cmd) {
+	if !s.active() {
+		return s, nil
+	}
+	top := len(s.stack) - 1
+	switch msg := msg.(type) {
+	case closeDialogMsg:
+		return s.close(), nil
+	case tea.KeyMsg:
+		if key.Matches(msg, closeDialog) {
+			return s.close(), nil
+		}
+	case tea.MouseMsg:
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft &&
+			!zone.Get(dialogZone(top)).InBounds(msg) {
+			return s.close(), nil
+		}
+	}
+	var cmd tea.Cmd
+	s.stack = append([]dialog(nil), s.stack...)
+	s.stack[top], cmd = s.stack[top].Update(msg)
+	return s, cmd
+}
+
+// broadcast hands msg to every dialog, for updates from work a dialog is showing
+// that keep coming whether or not it's on top
+func (s modals) broadcast(msg tea.Msg) (modals, tea.Cmd) {
+	var cmds []tea.Cmd
+	s.stack = append([]dialog(nil), s.stack...)
+	for i, d := range s.stack {
+		var cmd tea.Cmd
+		s.stack[i], cmd = d.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return s, tea.Batch(cmds...)
+}
+
+// views draws each dialog in its frame, bottom of the stack first
+func (s modals) views(width int) []string {
+	views := make([]string, len(s.stack))
+	for i, d := range s.stack {
+		views[i] = zone.Mark(dialogZone(i), dialogStyle.MaxWidth(width).Render(d.View()))
+	}
+	return views
+}
+
+// placeDialogs centres the dialog views over bg, each nudged down and right of the last
+func placeDialogs(bg string, views []string, width, height int) string {
+	for i, v := range views {
+		x := max((width-lipgloss.Width(v))/2+2*i, 0)
+		y := max((height-lipgloss.Height(v))/2+i, 0)
+		bg = placeOverlay(x, y, v, bg)
+	}
+	return bg
+}
+
+// textDialog is a read-only dialog with a title, e.g. the key help
+type textDialog struct {
+	title, body string
+}
+
+func (d textDialog) Update(tea.Msg) (dialog, tea.Cmd) {
+	return d, nil
+}
+
+func (d textDialog) View() string {
+	return dialogTitleStyle.Render(d.title) + "\n\n" + d.body
+}
+
+// promptDialog asks for one line of text and hands it to submit on enter.
+// Up and down step through history, newest first, when it has any, and tab
+// completes the value when it has a completer.
+type promptDialog struct {
+	title, note string
+	input       textinput.Model
+	submit      func(value string) tea.Msg
+	history     []string
+	recalled    int // how far back up arrow has gone, 0 for what was typed
+	typed       string
+	complete    func(value string) string // e.g. pathCompleter for prompts asking for a path
+}
+
+var (
+	promptSubmit = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "ok"))
+	promptOlder  = key.NewBinding(key.WithKeys("up"), key.WithHelp("↑/↓", "history"))
+	promptNewer  = key.NewBinding(key.WithKeys("down"))
+	promptTab    = key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "complete"))
+)
+
+func newPromptDialog(title, note, prompt, value string, submit func(string) tea.Msg) promptDialog {
+	ti := textinput.New()
+	ti.Prompt = prompt
+	ti.Width = 40
+	ti.SetValue(value)
+	ti.CursorEnd()
+	ti.Focus()
+	return promptDialog{title: title, note: note, input: ti, submit: submit}
+}
+
+func (d promptDialog) Update(msg tea.Msg) (dialog, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(k, promptSubmit):
+			value, submit := d.input.Value(), d.submit
+			return d, tea.Batch(closeTopDialog, func() tea.Msg { return submit(value) })
+		case key.Matches(k, promptTab) && d.complete != nil:
+			d.input.SetValue(d.complete(d.input.Value()))
+			d.input.CursorEnd()
+			return d, nil
+		case key.Matches(k, promptOlder) && d.recalled < len(d.history):
+			if d.recalled == 0 {
+				d.typed = d.input.Value()
+			}
+			d.recalled++
+			d.input.SetValue(d.history[d.recalled-1])
+			d.input.CursorEnd()
+			return d, nil
+		case key.Matches(k, promptNewer) && d.recalled > 0:
+			d.recalled--
+			if d.recalled == 0 {
+				d.input.SetValue(d.typed)
+			} else {
+				d.input.SetValue(d.history[d.recalled-1])
+			}
+			d.input.CursorEnd()
+			return d, nil
+		}
+	}
+	var cmd tea.Cmd
+	d.input, cmd = d.input.Update(msg)
+	return d, cmd
+}
+
+func (d promptDialog) View() string {
+	view := dialogTitleStyle.Render(d.title) + "\n\n"
+	if d.note != "" {
+		view += settingsDimStyle.Render(d.note) + "\n\n"
+	}
+	hints := []key.Binding{promptSubmit}
+	if len(d.history) > 0 {
+		hints = append(hints, promptOlder)
+	}
+	if d.complete != nil {
+		hints = append(hints, promptTab)
+	}
+	return view + d.input.View() + "\n\n" + help.New().ShortHelpView(append(hints, closeDialog))
+}
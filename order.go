@@ -0,0 +1,77 @@
+package main
+
+import (
+	"cmp"
+	"path/filepath"
+	"strings"
+)
+
+// resultOrder is how every match is ranked before the first page is taken,
+// unlike sortMode which only rearranges the rows already loaded
+type resultOrder int
+
+const (
+	orderNone  resultOrder = iota // the backend's own order, which lets a search stop early
+	orderName                     // by file name, then path
+	orderMtime                    // newest first
+	orderSize                     // largest first
+	orderCount
+)
+
+// orderNames are the config values, in settings screen order
+var orderNames = map[resultOrder]string{
+	orderNone:  "none",
+	orderName:  "name",
+	orderMtime: "mtime",
+	orderSize:  "size",
+}
+
+var orderDescriptions = map[resultOrder]string{
+	orderNone:  "plocate order",
+	orderName:  "name",
+	orderMtime: "newest first",
+	orderSize:  "largest first",
+}
+
+// parseOrder reads the config's order, falling back to none for anything unknown
+func parseOrder(name string) resultOrder {
+	for o, n := range orderNames {
+		if n == name {
+			return o
+		}
+	}
+	return orderNone
+}
+
+// needsStat is whether ranking looks at more than the path
+func (o resultOrder) needsStat() bool {
+	return o == orderMtime || o == orderSize
+}
+
+// compare ranks a before b when it comes first in o. Paths that couldn't be
+// statted go last, as do directories by size like sortSize, and ties keep
+// the shorter path first like byScore.
+func (o resultOrder) compare(a, b scoredPath) int {
+	var c int
+	switch o {
+	case orderName:
+		c = strings.Compare(strings.ToLower(filepath.Base(a.path)), strings.ToLower(filepath.Base(b.path)))
+	case orderMtime:
+		mtime := func(s scoredPath) int64 {
+			if s.info == nil {
+				return -1
+			}
+			return s.info.ModTime().UnixNano()
+		}
+		c = cmp.Compare(mtime(b), mtime(a))
+	case orderSize:
+		size := func(s scoredPath) int64 {
+			if s.info == nil || s.info.IsDir() {
+				return -1
+			}
+			return s.info.Size()
+		}
+		c = cmp.Compare(size(b), size(a))
+	}
+	return cmp.Or(c, cmp.Compare(len(a.path), len(b.path)), strings.Compare(a.path, b.path))
+}
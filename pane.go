@@ -0,0 +1,209 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The folder pane splits the table area, the results on the left and what's
+// in the selected result's directory on the right, for seeing what a match
+// sits next to without leaving the search. Each side keeps its own cursor;
+// tab moves between them while the results have focus.
+
+const (
+	paneShare    = 40 // percent of the width the folder pane takes
+	paneMinWidth = 80 // narrower terminals leave the pane out
+	paneGap      = 3  // the rule between the panes and a space either side
+)
+
+// paneState is the folder pane and the listing it shows
+type paneState struct {
+	on      bool
+	focused bool        // keys move the pane's cursor rather than the results'
+	path    string      // the result the pane follows, selected in the listing
+	dir     string      // the directory listed, or being listed
+	rows    []table.Row // the listing with whole paths, directories first
+	err     string      // why the directory couldn't be listed
+	table   table.Model
+}
+
+// paneListingMsg is a directory listed for the pane
+type paneListingMsg struct {
+	dir  string
+	rows []table.Row
+	err  error
+}
+
+func newPaneTable() table.Model {
+	return table.New(table.WithFocused(true), table.WithHeight(10))
+}
+
+// paneShown reports whether the pane is open and there's room to draw it
+func (m model) paneShown() bool {
+	return m.pane.on && m.width >= paneMinWidth
+}
+
+// paneWidth is the width of the folder pane, 0 when it isn't shown
+func (m model) paneWidth() int {
+	if !m.paneShown() {
+		return 0
+	}
+	return (m.width - 2) * paneShare / 100
+}
+
+// resultsWidth is what's left of the table area for the results
+func (m model) resultsWidth() int {
+	if !m.paneShown() {
+		return m.width - 2
+	}
+	return m.width - 2 - m.paneWidth() - paneGap
+}
+
+// togglePane opens or closes the folder pane
+func (m *model) togglePane() tea.Cmd {
+	if m.pane.on {
+		m.pane = paneState{table: m.pane.table}
+		m.resizeColumns()
+		return nil
+	}
+	m.pane.on = true
+	m.resizeColumns()
+	if !m.paneShown() {
+		return notify(toastWarn, "The window is too narrow for the folder pane, widen it to see it")
+	}
+	return notify(toastInfo, "Tab moves between the results and the folder pane")
+}
+
+// switchPane moves the keys between the results and the folder pane
+func (m *model) switchPane() {
+	m.pane.focused = !m.pane.focused
+	m.typeAhead = typeAhead{seq: m.typeAhead.seq}
+	m.register = registerNone
+}
+
+// resizePane fits the pane's columns in its width and its rows under its title
+func (m *model) resizePane() {
+	width := m.paneWidth()
+	sizeWidth := 10
+	m.pane.table.SetColumns([]table.Column{
+		{Title: "", Width: 2},
+		{Title: "Name", Width: max(width-2-sizeWidth-3*cellPadding(m.cfg.Density), 10)},
+		{Title: "Size", Width: sizeWidth},
+	})
+	m.pane.table.SetHeight(max(m.tableLines()-1, tableHeaderLines+1))
+	m.refreshPane()
+}
+
+// followSelection lists the selected result's directory when the selection
+// moves to another one, and puts the pane's cursor on the result
+func (m *model) followSelection() tea.Cmd {
+	if !m.pane.on {
+		return nil
+	}
+	row := m.selectedRow()
+	if row == nil || row[2] == m.pane.path {
+		return nil
+	}
+	m.pane.path = row[2]
+	dir := filepath.Dir(row[2])
+	if dir == m.pane.dir {
+		m.selectInPane(row[2])
+		return nil
+	}
+	m.pane.dir, m.pane.rows, m.pane.err = dir, nil, ""
+	m.refreshPane()
+	return listPaneDir(dir, m.siUnit, m.types)
+}
+
+// listPaneDir lists dir for the pane, directories first
+func listPaneDir(dir string, siUnit bool, types fileTypes) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := os.ReadDir(dir)
+		slices.SortStableFunc(entries, func(a, b os.DirEntry) int {
+			switch {
+			case a.IsDir() && !b.IsDir():
+				return -1
+			case b.IsDir() && !a.IsDir():
+				return 1
+			}
+			return 0
+		})
+		rows := make([]table.Row, 0, len(entries))
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			if info, err := e.Info(); err == nil {
+				rows = append(rows, buildRow(path, info, siUnit, types))
+			} else {
+				rows = append(rows, pendingRow(path, types))
+			}
+		}
+		return paneListingMsg{dir, rows, err}
+	}
+}
+
+// showListing puts a listing in the pane, unless the selection has moved on
+func (m *model) showListing(msg paneListingMsg) {
+	if !m.pane.on || msg.dir != m.pane.dir {
+		return
+	}
+	m.pane.rows = msg.rows
+	if msg.err != nil {
+		m.pane.err = firstLine(msg.err.Error())
+	}
+	m.refreshPane()
+	m.selectInPane(m.pane.path)
+}
+
+// refreshPane fills the pane's table from its listing, fitted to the columns
+func (m *model) refreshPane() {
+	cols := m.pane.table.Columns()
+	display := make([]table.Row, len(m.pane.rows))
+	for i, row := range m.pane.rows {
+		display[i] = table.Row{row[0], cleanCell(row[1]), row[3]}
+		if len(cols) == len(display[i]) {
+			display[i][1] = truncateRight(display[i][1], cols[1].Width)
+		}
+	}
+	m.pane.table.SetRows(display)
+}
+
+// selectInPane puts the pane's cursor on path, scrolled into view: SetCursor
+// can leave a row far down the listing just under the bottom edge
+func (m *model) selectInPane(path string) {
+	if i := slices.IndexFunc(m.pane.rows, func(r table.Row) bool { return r[2] == path }); i >= 0 {
+		m.pane.table.GotoTop()
+		m.pane.table.MoveDown(i)
+	}
+}
+
+// paneRow is the entry under the pane's cursor, with its full path
+func (m model) paneRow() table.Row {
+	if c := m.pane.table.Cursor(); c >= 0 && c < len(m.pane.rows) {
+		return m.pane.rows[c]
+	}
+	return nil
+}
+
+// splitView draws the results and the folder pane side by side, the pane
+// under a title naming its directory, lit up while it has focus
+func (m model) splitView(results string) string {
+	width := m.paneWidth()
+	titleStyle := settingsDimStyle
+	if m.pane.focused {
+		titleStyle = dialogTitleStyle
+	}
+	title := truncateLeft(m.displayPath(m.pane.dir), width)
+	if m.pane.err != "" {
+		title = truncateRight(title+": "+m.pane.err, width)
+	}
+	pane := titleStyle.Render(title) + "\n" + m.pane.table.View()
+	lines := max(lipgloss.Height(results), lipgloss.Height(pane))
+	rule := groupRuleStyle.Render(strings.TrimSuffix(strings.Repeat(" │ \n", lines), "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.PlaceHorizontal(m.resultsWidth(), lipgloss.Left, results), rule, pane)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var pasteJump = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "jump to it"))
+
+// jumpToPathMsg shows a single path in the table instead of searching for it
+type jumpToPathMsg struct {
+	path string
+}
+
+// pastedPath reports whether a bracketed paste is a path that exists, e.g. one
+// copied from a file manager or another terminal, rather than a search term
+func pastedPath(text, home string) (string, bool) {
+	text = strings.Trim(strings.TrimSpace(text), `'"`)
+	if strings.ContainsAny(text, "\n\r") {
+		return "", false
+	}
+	if u, err := url.Parse(text); err == nil && u.Scheme == "file" {
+		text = u.Path
+	}
+	if rest, ok := strings.CutPrefix(text, "~/"); ok && home != "" {
+		text = home + "/" + rest
+	}
+	if !strings.HasPrefix(text, "/") {
+		return "", false
+	}
+	if _, err := os.Lstat(text); err != nil {
+		return "", false
+	}
+	return text, true
+}
+
+// pasteDialog offers to jump to a pasted path. Closing it leaves the paste in
+// the input as an ordinary search.
+type pasteDialog struct {
+	path string
+}
+
+func (d pasteDialog) Update(msg tea.Msg) (dialog, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok && key.Matches(k, pasteJump) {
+		path := d.path
+		return d, tea.Batch(closeTopDialog, func() tea.Msg { return jumpToPathMsg{path} })
+	}
+	return d, nil
+}
+
+func (d pasteDialog) View() string {
+	return dialogTitleStyle.Render("Pasted a path") + "\n\n" + d.path + "\n\n" +
+		help.New().ShortHelpView([]key.Binding{pasteJump, key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "search for it"))})
+}
+
+// jumpTo replaces the results with path itself, without asking plocate
+func (m *model) jumpTo(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		m.statusMessage = err.Error()
+		return
+	}
+	if m.cancelSearch != nil {
+		m.cancelSearch()
+	}
+	m.searchSeq++ // drop any debounced search still on its way
+	m.textInput.SetValue(path)
+	m.textInput.CursorEnd()
+	m.searchQuery, m.lastQuery, m.shownQuery = path, path, path
+	m.itemLimit, m.lastItemLimit = m.visibleRows, m.visibleRows
+	row := buildRow(path, info, m.siUnit, m.types)
+	m.infos = map[rowID]os.FileInfo{idOf(row): info}
+	m.setResults([]table.Row{row})
+	m.table.SetCursor(0)
+	m.statusMessage = "Jumped to " + path
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Changing permissions works on the selection and everything under the
+// directories in it, for cleaning up a wrongly owned tree found by a search.
+// What would change is listed first, and only applied once that's confirmed.
+
+// permChange is a parsed mode and owner change, like chmod and chown take them
+type permChange struct {
+	mode     func(old uint32) uint32 // nil leaves the mode alone
+	uid, gid int                     // -1 leaves them alone
+}
+
+// parsePermChange reads a mode, an owner, or both separated by a space: an
+// octal mode like 644 or symbolic ones like u+x,go-w, and an owner like ann,
+// ann:staff or :staff, by name or number. Symbolic modes without u, g or o
+// apply to all three, whatever the umask.
+func parsePermChange(spec string) (permChange, error) {
+	c := permChange{uid: -1, gid: -1}
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || len(fields) > 2 {
+		return c, errors.New("give a mode, an owner or both, e.g. 644, u+x,go-w or ann:staff")
+	}
+	for _, f := range fields {
+		if strings.ContainsAny(f, "+-=") || strings.Trim(f, "01234567") == "" {
+			if c.mode != nil {
+				return c, fmt.Errorf("%s: only one mode at a time", f)
+			}
+			mode, err := parseMode(f)
+			if err != nil {
+				return c, err
+			}
+			c.mode = mode
+			continue
+		}
+		if c.uid >= 0 || c.gid >= 0 {
+			return c, fmt.Errorf("%s: only one owner at a time", f)
+		}
+		var err error
+		if c.uid, c.gid, err = parseOwner(f); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// parseMode understands an octal mode or comma separated symbolic ones
+func parseMode(s string) (func(uint32) uint32, error) {
+	if strings.Trim(s, "01234567") == "" {
+		n, err := strconv.ParseUint(s, 8, 32)
+		if err != nil || len(s) < 3 || len(s) > 4 {
+			return nil, fmt.Errorf("%s: an octal mode has 3 or 4 digits, e.g. 644", s)
+		}
+		return func(uint32) uint32 { return uint32(n) }, nil
+	}
+	var steps []func(uint32) uint32
+	for _, clause := range strings.Split(s, ",") {
+		i := strings.IndexAny(clause, "+-=")
+		who, op, perms := clause[:max(i, 0)], byte(0), ""
+		if i >= 0 {
+			op, perms = clause[i], clause[i+1:]
+		}
+		if i < 0 || strings.Trim(who, "ugoa") != "" || strings.Trim(perms, "rwxst") != "" {
+			return nil, fmt.Errorf("%s: expected a mode like u+x, go-w or a=r", clause)
+		}
+		if who == "" || strings.Contains(who, "a") {
+			who = "ugo"
+		}
+		var mask, bits uint32
+		for _, w := range who {
+			shift := map[rune]uint{'u': 6, 'g': 3, 'o': 0}[w]
+			mask |= 0o7 << shift
+			for _, p := range perms {
+				switch p {
+				case 'r':
+					bits |= 0o4 << shift
+				case 'w':
+					bits |= 0o2 << shift
+				case 'x':
+					bits |= 0o1 << shift
+				}
+			}
+			special := map[rune]uint32{'u': 0o4000, 'g': 0o2000, 'o': 0o1000}[w]
+			mask |= special
+			if w != 'o' && strings.Contains(perms, "s") || w == 'o' && strings.Contains(perms, "t") {
+				bits |= special
+			}
+		}
+		steps = append(steps, func(m uint32) uint32 {
+			switch op {
+			case '+':
+				return m | bits
+			case '-':
+				return m &^ bits
+			}
+			return m&^mask | bits
+		})
+	}
+	return func(m uint32) uint32 {
+		for _, step := range steps {
+			m = step(m)
+		}
+		return m
+	}, nil
+}
+
+// parseOwner reads user, user:group or :group, each a name or a number
+func parseOwner(s string) (uid, gid int, err error) {
+	name, group, _ := strings.Cut(s, ":")
+	uid, gid = -1, -1
+	if name != "" {
+		if uid, err = strconv.Atoi(name); err != nil {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return -1, -1, fmt.Errorf("%s: no such user", name)
+			}
+			uid, _ = strconv.Atoi(u.Uid)
+		}
+	}
+	if group != "" {
+		if gid, err = strconv.Atoi(group); err != nil {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return -1, -1, fmt.Errorf("%s: no such group", group)
+			}
+			gid, _ = strconv.Atoi(g.Gid)
+		}
+	}
+	if uid < 0 && gid < 0 {
+		return -1, -1, fmt.Errorf("%s: expected an owner like ann, ann:staff or :staff", s)
+	}
+	return uid, gid, nil
+}
+
+// chmodBits is the permission bits of a mode as chmod numbers them
+func chmodBits(m fs.FileMode) uint32 {
+	bits := uint32(m.Perm())
+	if m&fs.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if m&fs.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if m&fs.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return bits
+}
+
+// chmodMode turns chmod's numbering back into what os.Chmod takes
+func chmodMode(bits uint32) fs.FileMode {
+	m := fs.FileMode(bits) & fs.ModePerm
+	if bits&0o4000 != 0 {
+		m |= fs.ModeSetuid
+	}
+	if bits&0o2000 != 0 {
+		m |= fs.ModeSetgid
+	}
+	if bits&0o1000 != 0 {
+		m |= fs.ModeSticky
+	}
+	return m
+}
+
+// permStep is what changes on one file
+type permStep struct {
+	path           string
+	oldMode, mode  uint32
+	oldUID, oldGID int
+	uid, gid       int
+	err            error // why it couldn't be changed, once applied
+	modeDiffers    bool
+	ownerDiffers   bool
+}
+
+// planPerms lists the files under paths that c would change, walking into
+// directories without following symlinks, which it leaves alone: chmod
+// would change what they point to rather than the link
+func planPerms(ctx context.Context, paths []string, c permChange) ([]permStep, error) {
+	var steps []permStep
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := pauseWhileTyping(ctx); err != nil {
+				return err
+			}
+			if d.Type()&fs.ModeSymlink != 0 {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			st, ok := info.Sys().(*syscall.Stat_t)
+			if !ok {
+				return fmt.Errorf("%s: no owner to compare with", path)
+			}
+			s := permStep{path: path, oldMode: chmodBits(info.Mode()), oldUID: int(st.Uid), oldGID: int(st.Gid)}
+			s.mode, s.uid, s.gid = s.oldMode, s.oldUID, s.oldGID
+			if c.mode != nil {
+				s.mode = c.mode(s.oldMode)
+			}
+			if c.uid >= 0 {
+				s.uid = c.uid
+			}
+			if c.gid >= 0 {
+				s.gid = c.gid
+			}
+			s.modeDiffers, s.ownerDiffers = s.mode != s.oldMode, s.uid != s.oldUID || s.gid != s.oldGID
+			if s.modeDiffers || s.ownerDiffers {
+				steps = append(steps, s)
+			}
+			return nil
+		})
+		if err != nil {
+			return steps, err
+		}
+	}
+	return steps, nil
+}
+
+// applyPerms makes each change, owner first since chown clears the setuid
+// and setgid bits, and reports the files it failed on
+func applyPerms(ctx context.Context, steps []permStep) (failed []permStep) {
+	for i, s := range steps {
+		if err := pauseWhileTyping(ctx); err != nil {
+			return failed
+		}
+		if s.ownerDiffers {
+			s.err = os.Lchown(s.path, s.uid, s.gid)
+		}
+		if s.err == nil && (s.modeDiffers || s.ownerDiffers && s.mode&0o6000 != 0) {
+			s.err = os.Chmod(s.path, chmodMode(s.mode))
+		}
+		if s.err != nil {
+			failed = append(failed, s)
+		}
+		reportProgress(ctx, int64(i+1), int64(len(steps)))
+	}
+	return failed
+}
+
+// describe is a step as the preview and the error report list it
+func (s permStep) describe() string {
+	var parts []string
+	if s.modeDiffers {
+		parts = append(parts, fmt.Sprintf("%04o → %04o", s.oldMode, s.mode))
+	}
+	if s.ownerDiffers {
+		parts = append(parts, ownerName(s.oldUID, s.oldGID)+" → "+ownerName(s.uid, s.gid))
+	}
+	return strings.Join(parts, "  ") + "  " + s.path
+}
+
+func ownerName(uid, gid int) string {
+	group := strconv.Itoa(gid)
+	if g, err := user.LookupGroupId(group); err == nil {
+		group = g.Name
+	}
+	return userName(strconv.Itoa(uid)) + ":" + group
+}
+
+// newPermsPrompt asks how to change the permissions of paths
+func newPermsPrompt(paths []string) promptDialog {
+	note := fmt.Sprintf("For %d files and everything in the directories among them. A mode like 644 or u+x,go-w, an owner like ann:staff or :staff, or both. Nothing changes until you've seen what would.", len(paths))
+	d := newPromptDialog("Change permissions", note, "chmod/chown ", "", func(spec string) tea.Msg {
+		return permsSpecMsg{strings.TrimSpace(spec), paths}
+	})
+	d.history = loadHistory("permissions")
+	return d
+}
+
+// permsSpecMsg is the change typed into the permissions prompt
+type permsSpecMsg struct {
+	spec  string
+	paths []string
+}
+
+// permsPlanMsg is what a change would do, for the preview
+type permsPlanMsg struct {
+	spec  string
+	paths []string
+	steps []permStep
+	err   error
+}
+
+// permsApplyMsg confirms the preview
+type permsApplyMsg struct {
+	spec  string
+	paths []string
+	steps []permStep
+}
+
+// permsDoneMsg reports a change once it's been made to every file it could
+type permsDoneMsg struct {
+	spec    string
+	paths   []string
+	changed int
+	failed  []permStep
+}
+
+// failure is the error the audit log and the toast give for the files that failed
+func (msg permsDoneMsg) failure() error {
+	if len(msg.failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed for %d files", len(msg.failed))
+}
+
+// planPermsJob works out what spec would change, as a job since it walks the directories
+func (m model) planPermsJob(spec string, paths []string) tea.Cmd {
+	c, err := parsePermChange(spec)
+	if err != nil {
+		return notify(toastError, err.Error())
+	}
+	return tea.Batch(m.jobs.run(jobPerms, "check "+spec, func(ctx context.Context) tea.Msg {
+		steps, err := planPerms(ctx, paths, c)
+		return permsPlanMsg{spec, paths, steps, err}
+	}), m.jobs.watchProgress())
+}
+
+// applyPermsJob makes the changes the preview listed
+func (m model) applyPermsJob(msg permsApplyMsg) tea.Cmd {
+	logged := auditEntry{Action: "permissions", Paths: msg.paths, Target: msg.spec}
+	return tea.Batch(m.jobs.runAudited(jobPerms, "chmod/chown "+msg.spec, logged, func(ctx context.Context, _ *auditEntry) (tea.Msg, error) {
+		failed := applyPerms(ctx, msg.steps)
+		done := permsDoneMsg{msg.spec, msg.paths, len(msg.steps) - len(failed), failed}
+		return done, done.failure()
+	}), m.jobs.watchProgress())
+}
+
+// permsReport is the per-file errors of a change, for the dialog shown when any failed
+func permsReport(msg permsDoneMsg) string {
+	var b strings.Builder
+	for _, s := range msg.failed {
+		fmt.Fprintf(&b, "%s\n  %s\n", s.path, s.err)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+var permsApply = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "apply"))
+
+// permsDialog previews a change, applying it on enter
+type permsDialog struct {
+	msg      permsPlanMsg
+	viewport viewport.Model
+}
+
+func newPermsDialog(msg permsPlanMsg, width, height int) permsDialog {
+	lines := make([]string, len(msg.steps))
+	for i, s := range msg.steps {
+		lines[i] = s.describe()
+	}
+	vp := viewport.New(max(width-8, 20), min(max(height-12, 5), max(len(lines), 1)))
+	vp.SetContent(strings.Join(lines, "\n"))
+	return permsDialog{msg: msg, viewport: vp}
+}
+
+func (d permsDialog) Update(msg tea.Msg) (dialog, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok && key.Matches(k, permsApply) && len(d.msg.steps) > 0 {
+		apply := permsApplyMsg{d.msg.spec, d.msg.paths, d.msg.steps}
+		return d, tea.Batch(closeTopDialog, func() tea.Msg { return apply })
+	}
+	var cmd tea.Cmd
+	d.viewport, cmd = d.viewport.Update(msg)
+	return d, cmd
+}
+
+func (d permsDialog) View() string {
+	summary := fmt.Sprintf("%d files would change", len(d.msg.steps))
+	if len(d.msg.steps) == 1 {
+		summary = "1 file would change"
+	}
+	hints := []key.Binding{pagerScroll, closeDialog}
+	if len(d.msg.steps) > 0 {
+		hints = append([]key.Binding{permsApply}, hints...)
+	} else {
+		summary = "Nothing would change"
+	}
+	return dialogTitleStyle.Render("chmod/chown "+d.msg.spec) + "\n\n" + d.viewport.View() + "\n\n" + summary + "\n\n" +
+		help.New().ShortHelpView(hints)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePermChange(t *testing.T) {
+	for _, tc := range []struct {
+		spec     string
+		old, new uint32
+	}{
+		{"755", 0o644, 0o755},
+		{"u+x", 0o644, 0o744},
+		{"go-w", 0o666, 0o644},
+		{"+x", 0o644, 0o755},
+		{"a=r", 0o755, 0o444},
+		{"u=rw,go=", 0o777, 0o600},
+		{"u+s", 0o755, 0o4755},
+		{"o+t", 0o777, 0o1777},
+		{"g-s", 0o2775, 0o775},
+	} {
+		c, err := parsePermChange(tc.spec)
+		if err != nil {
+			t.Errorf("%s: %v", tc.spec, err)
+			continue
+		}
+		if got := c.mode(tc.old); got != tc.new {
+			t.Errorf("%s on %04o = %04o, want %04o", tc.spec, tc.old, got, tc.new)
+		}
+		if c.uid != -1 || c.gid != -1 {
+			t.Errorf("%s changes the owner to %d:%d", tc.spec, c.uid, c.gid)
+		}
+	}
+
+	c, err := parsePermChange("644 1000:100")
+	if err != nil || c.mode == nil || c.uid != 1000 || c.gid != 100 {
+		t.Errorf("644 1000:100 = %+v, %v", c, err)
+	}
+	if c, err := parsePermChange(":100"); err != nil || c.mode != nil || c.uid != -1 || c.gid != 100 {
+		t.Errorf(":100 = %+v, %v", c, err)
+	}
+	for _, spec := range []string{"", "75", "u+q", "z+x", "644 755", "0:0 1:1", "no-such-user-here", "644 1 2"} {
+		if _, err := parsePermChange(spec); err == nil {
+			t.Errorf("%q parsed", spec)
+		}
+	}
+}
+
+func TestPlanAndApplyPerms(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, mode := range map[string]os.FileMode{"a": 0o600, "b": 0o640} {
+		if err := os.WriteFile(filepath.Join(sub, name), nil, mode); err != nil {
+			t.Fatal(err)
+		}
+		os.Chmod(filepath.Join(sub, name), mode) // whatever the umask
+	}
+	if err := os.Symlink("a", filepath.Join(sub, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := parsePermChange("g+r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := planPerms(context.Background(), []string{sub}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sub already has g+r and so does b, and the link is left alone
+	if len(steps) != 1 || steps[0].path != filepath.Join(sub, "a") || steps[0].mode != 0o640 {
+		t.Fatalf("planned %+v, want only a to 0640", steps)
+	}
+	if got := steps[0].describe(); got != "0600 → 0640  "+filepath.Join(sub, "a") {
+		t.Errorf("describe = %q", got)
+	}
+
+	if failed := applyPerms(context.Background(), steps); len(failed) != 0 {
+		t.Fatalf("failed: %v", failed[0].err)
+	}
+	info, err := os.Stat(filepath.Join(sub, "a"))
+	if err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("a is %v after applying, want -rw-r-----", info.Mode())
+	}
+	if steps, _ := planPerms(context.Background(), []string{sub}, c); len(steps) != 0 {
+		t.Errorf("applying again would change %d files", len(steps))
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pipeCommandMsg is the command typed into the pipe prompt
+type pipeCommandMsg struct {
+	command string
+}
+
+// pipeMsg is what a pipe command printed once it's done
+type pipeMsg struct {
+	command string
+	output  string
+	count   int // paths piped in
+	err     error
+}
+
+// newPipeDialog prompts for a shell command to feed the results to, recalling earlier ones
+func newPipeDialog() promptDialog {
+	d := newPromptDialog("Pipe results", "Every match, null-separated, e.g. xargs -0 du -ch", "| ", "", func(command string) tea.Msg {
+		return pipeCommandMsg{strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(command), "|"))}
+	})
+	d.history = loadHistory("pipe")
+	return d
+}
+
+// pipeResults runs the query again without a limit and writes every match to
+// command's stdin, each path ended by a NUL so xargs -0 and friends split them
+// right. It runs as a job, so it can be cancelled from the jobs dialog.
+func (m model) pipeResults(command string) tea.Cmd {
+	q, err := m.prepareQuery(m.searchQuery)
+	if err != nil {
+		return func() tea.Msg {
+			return logged(pipeMsg{command: command, err: err}, auditEntry{Action: "pipe", Target: command}, err)
+		}
+	}
+	return tea.Batch(m.jobs.runAudited(jobPipe, "pipe to "+command, auditEntry{Action: "pipe", Target: command}, func(ctx context.Context, _ *auditEntry) (tea.Msg, error) {
+		var input bytes.Buffer
+		count := 0
+		err := q.run(ctx, func(path, _ string, _ os.FileInfo) {
+			input.WriteString(path)
+			input.WriteByte(0)
+			count++
+		})
+		if err != nil {
+			return pipeMsg{command: command, err: err}, err
+		}
+		c := exec.CommandContext(ctx, "sh", "-c", command)
+		c.Stdin = &input
+		c.WaitDelay = time.Second // what sh started may hold the output open after it's killed
+		out, err := c.CombinedOutput()
+		return pipeMsg{command: command, output: string(out), count: count, err: err}, err
+	}), m.jobs.watchProgress())
+}
+
+// pipeOutput is the pager text for a finished pipe, with why it failed if it did
+func pipeOutput(msg pipeMsg) string {
+	text := msg.output
+	var exit *exec.ExitError
+	switch {
+	case errors.As(msg.err, &exit):
+		text += "\n[exit status " + strings.TrimPrefix(exit.Error(), "exit status ") + "]"
+	case msg.err != nil:
+		text += "\n" + msg.err.Error()
+	case text == "":
+		text = "(no output)"
+	}
+	return text
+}
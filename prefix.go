@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newPrefixInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "in "
+	ti.Placeholder = "path prefix, tab completes"
+	ti.CharLimit = 256
+	return ti
+}
+
+// prefixKey handles the second input that keeps results to paths starting
+// with what's typed in it, opened and closed with its key. Tab completes a
+// directory, enter goes back to the query keeping the prefix, esc drops it.
+// Every change searches again like typing in the query does.
+func (m *model) prefixKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	if !m.prefix.Focused() {
+		if key.Matches(msg, m.keys.Prefix) {
+			m.setTableFocus(false)
+			m.textInput.Blur()
+			return true, m.prefix.Focus()
+		}
+		return false, nil
+	}
+	before := m.prefix.Value()
+	switch {
+	case key.Matches(msg, m.keys.Prefix), msg.Type == tea.KeyEnter:
+		m.prefix.Blur()
+		m.textInput.Focus()
+		return true, nil
+	case msg.Type == tea.KeyEsc:
+		m.prefix.Blur()
+		m.prefix.SetValue("")
+		m.textInput.Focus()
+	case msg.Type == tea.KeyTab:
+		m.prefix.SetValue(completePath(before, m.home, true))
+		m.prefix.CursorEnd()
+	default:
+		m.prefix, cmd = m.prefix.Update(msg)
+	}
+	if m.prefix.Value() != before {
+		m.lastQuery = "" // search again under the new prefix
+	}
+	return true, cmd
+}
+
+// prefixPath is the typed prefix with ~ expanded, "" for none
+func (m model) prefixPath() string {
+	return expandHome(strings.TrimSpace(m.prefix.Value()), m.home)
+}
+
+// prefixFilter keeps the paths starting with prefix
+func prefixFilter(prefix string) filter {
+	return filter{keep: func(path string, _ os.FileInfo) bool {
+		return strings.HasPrefix(path, prefix)
+	}}
+}
+
+// prefixDir is the directory a prefix is certainly inside, for backends
+// that walk to start from: all of it if it ends in /, its parent otherwise
+func prefixDir(prefix string) string {
+	if strings.HasSuffix(prefix, "/") {
+		return filepath.Clean(prefix)
+	}
+	return filepath.Dir(prefix)
+}
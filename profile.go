@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// profile bundles filters, a sort order and column choices under a name, e.g.
+// "media" or "code", so switching what you're hunting for is one key
+type profile struct {
+	Name         string   `json:"name"`
+	Extensions   []string `json:"extensions,omitempty"`   // keep only these, without the dot
+	ExcludeDirs  []string `json:"exclude_dirs,omitempty"` // drop paths passing through a directory with one of these names
+	Sort         string   `json:"sort,omitempty"`         // "depth", "size" or empty for plocate order
+	ShowSize     *bool    `json:"show_size,omitempty"`    // nil leaves the setting alone
+	ShowModified *bool    `json:"show_modified,omitempty"`
+}
+
+func defaultProfiles() []profile {
+	yes := true
+	return []profile{
+		{
+			Name:       "media",
+			Extensions: []string{"mp4", "mkv", "mov", "webm", "avi", "mp3", "flac", "ogg", "opus", "wav", "m4a"},
+			Sort:       "size",
+			ShowSize:   &yes,
+		},
+		{
+			Name:         "code",
+			Extensions:   []string{"go", "rs", "c", "h", "cpp", "hpp", "py", "js", "ts", "tsx", "java", "rb", "sh", "lua", "zig"},
+			ExcludeDirs:  []string{"node_modules", "target", "build", "dist", ".git", "vendor", "__pycache__"},
+			ShowModified: &yes,
+		},
+	}
+}
+
+// profiles are the user's from the config, or the built-in ones if there are none
+func (m model) profiles() []profile {
+	if len(m.cfg.Profiles) > 0 {
+		return m.cfg.Profiles
+	}
+	return defaultProfiles()
+}
+
+func (m model) activeProfile() *profile {
+	ps := m.profiles()
+	if m.profile < 0 || m.profile >= len(ps) {
+		return nil
+	}
+	return &ps[m.profile]
+}
+
+// nextProfile cycles none -> each profile -> none, re-running the search under it
+func (m *model) nextProfile() tea.Cmd {
+	next := m.profile + 1
+	if next >= len(m.profiles()) {
+		next = -1
+	}
+	return m.useProfile(next)
+}
+
+// useProfile switches to the ith profile, or none for -1
+func (m *model) useProfile(i int) tea.Cmd {
+	m.profile = i
+	name := "none"
+	m.textInput.Prompt = "> "
+	m.sortMode = sortNatural
+	if p := m.activeProfile(); p != nil {
+		name = p.Name
+		m.textInput.Prompt = p.Name + "> "
+		for mode, n := range sortModeNames {
+			if n == p.Sort {
+				m.sortMode = mode
+			}
+		}
+	}
+	m.resizeColumns()
+	m.lastQuery = "" // search again with the profile's filters
+	return notify(toastInfo, "Profile: "+name)
+}
+
+// filters are the profile's clauses, all decided from the path alone
+func (p profile) filters() []filter {
+	var fs []filter
+	if len(p.Extensions) > 0 {
+		fs = append(fs, filter{keep: func(path string, _ os.FileInfo) bool {
+			ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+			return slices.Contains(p.Extensions, ext)
+		}})
+	}
+	if len(p.ExcludeDirs) > 0 {
+		fs = append(fs, filter{keep: func(path string, _ os.FileInfo) bool {
+			dirs := strings.Split(filepath.Dir(path), "/")
+			return !slices.ContainsFunc(dirs, func(d string) bool { return slices.Contains(p.ExcludeDirs, d) })
+		}})
+	}
+	return fs
+}
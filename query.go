@@ -0,0 +1,407 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+)
+
+// query is the text input split into the patterns handed to plocate and the
+// clauses gocate applies itself to each path plocate prints. Each alternative
+// is one plocate run whose patterns must all match; a path matching any
+// alternative and none of the excludes is a result.
+type query struct {
+	alts     [][]string
+	fallback bool // alts weren't typed but stand in for a query of clauses only, as plain substrings
+	excludes []string
+	opts     searchOpts
+	from     searcher // the locate database, or the filesystem itself with live search
+	filters  []filter
+	contains []string              // content: clauses, every one of which a file has to contain
+	score    func(path string) int // set to keep the best scoring matches rather than the first ones
+	order    resultOrder           // otherwise, how to rank every match before keeping the first ones
+	depth    int                   // how far below the search root results may be, -1 for anywhere
+	types    fileTypes             // what type: clauses and the rows' icons go by
+}
+
+// filter keeps or drops one result; info is only looked up when needsStat is set
+type filter struct {
+	needsStat bool
+	keep      func(path string, info os.FileInfo) bool
+}
+
+// matchAll is the pattern used when a query is made up of clauses only
+const matchAll = "/"
+
+// parseQuery pulls key:value clauses out of the input and splits the rest into
+// terms on spaces, like plocate foo bar. Quote a term to search for a space.
+// A lone | separates alternatives and !term excludes paths matching term.
+func parseQuery(input string, types fileTypes) (query, error) {
+	q := query{depth: -1, types: types}
+	var terms, owners, typeNames, exts []string
+	var parent string
+	for _, tok := range fields(input) {
+		if tok == "|" {
+			if len(terms) > 0 {
+				q.alts = append(q.alts, terms)
+			}
+			terms = nil
+			continue
+		}
+		if t, ok := strings.CutPrefix(tok, "!"); ok && t != "" {
+			q.excludes = append(q.excludes, strings.Trim(t, `"`))
+			continue
+		}
+		k, v, ok := strings.Cut(tok, ":")
+		v = strings.Trim(v, `"`)
+		switch {
+		case ok && k == "name":
+			if v == "" {
+				return q, fmt.Errorf("name: needs part of a file name, e.g. name:report")
+			}
+			terms = append(terms, v)
+		case ok && k == "content":
+			if v == "" {
+				return q, fmt.Errorf("content: needs text to look for inside files, e.g. content:invoice")
+			}
+			q.contains = append(q.contains, v)
+		case ok && k == "owner":
+			if v == "" {
+				return q, fmt.Errorf("owner: needs a user name or uid")
+			}
+			owners = append(owners, v)
+		case ok && k == "type":
+			if v == "" {
+				return q, fmt.Errorf("type: needs a file type, e.g. type:images")
+			}
+			typeNames = append(typeNames, v)
+		case ok && k == "ext":
+			var these []string
+			for _, e := range strings.Split(v, ",") {
+				if e = strings.TrimPrefix(e, "."); e != "" {
+					these = append(these, e)
+				}
+			}
+			if len(these) == 0 {
+				return q, fmt.Errorf("ext: needs a file extension, e.g. ext:pdf or ext:jpg,png")
+			}
+			exts = append(exts, these...)
+		case ok && k == "size":
+			f, err := sizeFilter(v)
+			if err != nil {
+				return q, err
+			}
+			q.filters = append(q.filters, f)
+		case ok && k == "mtime":
+			f, err := mtimeFilter(v, time.Now())
+			if err != nil {
+				return q, err
+			}
+			q.filters = append(q.filters, f)
+		case ok && k == "perm":
+			f, err := permFilter(v)
+			if err != nil {
+				return q, err
+			}
+			q.filters = append(q.filters, f)
+		case ok && k == "parent":
+			if !strings.HasPrefix(v, "/") {
+				return q, fmt.Errorf("parent: needs an absolute directory, e.g. parent:/etc")
+			}
+			dir := filepath.Clean(v)
+			parent = dir
+			q.filters = append(q.filters, filter{keep: func(path string, _ os.FileInfo) bool {
+				return filepath.Dir(path) == dir
+			}})
+		case ok && k == "depth":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return q, fmt.Errorf("depth:%s: expected a number of directories, e.g. depth:3", v)
+			}
+			q.depth = n // counted from the search root, which prepareQuery knows
+		default:
+			if t := strings.Trim(tok, `"`); t != "" {
+				terms = append(terms, t)
+			}
+		}
+	}
+
+	if len(owners) > 0 {
+		q.filters = append(q.filters, ownerFilter(owners))
+	}
+	if len(exts) > 0 {
+		q.filters = append(q.filters, filter{keep: func(path string, _ os.FileInfo) bool {
+			ext := strings.TrimPrefix(filepath.Ext(path), ".")
+			return slices.ContainsFunc(exts, func(e string) bool { return strings.EqualFold(e, ext) })
+		}})
+	}
+	if len(typeNames) > 0 {
+		f, err := typeFilter(typeNames, types)
+		if err != nil {
+			return q, err
+		}
+		q.filters = append(q.filters, f)
+	}
+	if len(terms) > 0 {
+		q.alts = append(q.alts, terms)
+	}
+	if len(q.alts) == 0 {
+		q.fallback = true
+		switch {
+		case parent != "":
+			q.alts = [][]string{{strings.TrimSuffix(parent, "/") + "/"}} // narrow plocate down to the directory rather than scanning everything
+		case len(q.contains) > 0 && len(q.filters) == 0 && q.depth < 0:
+			return q, fmt.Errorf("content: reads every file it's given, narrow them down first, e.g. name:report content:invoice")
+		case len(q.filters) > 0 || len(q.excludes) > 0 || q.depth >= 0:
+			q.alts = [][]string{{matchAll}}
+		}
+	}
+	return q, nil
+}
+
+// fields splits input on spaces like strings.Fields, except inside double
+// quotes, so a clause like parent:"/home/me/My Documents" stays one token
+func fields(input string) []string {
+	var toks []string
+	var cur strings.Builder
+	quoted := false
+	for _, r := range input {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			cur.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if cur.Len() > 0 {
+				toks = append(toks, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		toks = append(toks, cur.String())
+	}
+	return toks
+}
+
+// quoteValue quotes a clause value that fields would otherwise split
+func quoteValue(v string) string {
+	if strings.ContainsFunc(v, unicode.IsSpace) {
+		return `"` + v + `"`
+	}
+	return v
+}
+
+// keep reports whether path passes every clause, statting it at most once
+func (q query) keep(path string) (os.FileInfo, bool) {
+	var info os.FileInfo
+	for _, f := range q.filters {
+		if f.needsStat && info == nil {
+			var err error
+			if info, err = statPath(path); err != nil {
+				return nil, false
+			}
+		}
+		if !f.keep(path, info) {
+			return info, false
+		}
+	}
+	return info, true
+}
+
+// pathDepth counts the components of an absolute path, so /etc is 1 and /etc/fstab is 2
+func pathDepth(path string) int {
+	return strings.Count(strings.TrimSuffix(path, "/"), "/")
+}
+
+// depthFilter keeps paths at most n components below base, so with base
+// /home/ann, /home/ann/docs is 1 deep and /home/ann/docs/cv.pdf 2
+func depthFilter(base string, n int) filter {
+	below := pathDepth(base)
+	return filter{keep: func(path string, _ os.FileInfo) bool {
+		return pathDepth(path)-below <= n
+	}}
+}
+
+// ownerFilter matches files owned by any of the given user names or uids
+func ownerFilter(owners []string) filter {
+	return filter{needsStat: true, keep: func(_ string, info os.FileInfo) bool {
+		st, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return false
+		}
+		uid := strconv.FormatUint(uint64(st.Uid), 10)
+		return slices.Contains(owners, uid) || slices.Contains(owners, userName(uid))
+	}}
+}
+
+// permFilter understands the special bits by name, +x/-x style checks across
+// user, group and other, and an exact octal mode like 644
+func permFilter(v string) (filter, error) {
+	has := func(bits os.FileMode) filter {
+		return filter{needsStat: true, keep: func(_ string, info os.FileInfo) bool { return info.Mode()&bits != 0 }}
+	}
+	switch v {
+	case "suid":
+		return has(os.ModeSetuid), nil
+	case "sgid":
+		return has(os.ModeSetgid), nil
+	case "sticky":
+		return has(os.ModeSticky), nil
+	case "world-writable":
+		return has(0o002), nil
+	case "world-readable":
+		return has(0o004), nil
+	}
+
+	if len(v) == 2 && (v[0] == '+' || v[0] == '-') {
+		bits := map[byte]os.FileMode{'r': 0o444, 'w': 0o222, 'x': 0o111}[v[1]]
+		if bits == 0 {
+			return filter{}, fmt.Errorf("perm:%s: expected r, w or x after %c", v, v[0])
+		}
+		want := v[0] == '+'
+		return filter{needsStat: true, keep: func(_ string, info os.FileInfo) bool {
+			return (info.Mode()&bits != 0) == want
+		}}, nil
+	}
+
+	if mode, err := strconv.ParseUint(v, 8, 32); err == nil && len(v) >= 3 && len(v) <= 4 {
+		want := os.FileMode(mode) & os.ModePerm
+		special := os.FileMode(0)
+		if mode&0o4000 != 0 {
+			special |= os.ModeSetuid
+		}
+		if mode&0o2000 != 0 {
+			special |= os.ModeSetgid
+		}
+		if mode&0o1000 != 0 {
+			special |= os.ModeSticky
+		}
+		return filter{needsStat: true, keep: func(_ string, info os.FileInfo) bool {
+			m := info.Mode()
+			return m.Perm() == want && m&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky) == special
+		}}, nil
+	}
+	return filter{}, fmt.Errorf("perm:%s: use suid, sgid, sticky, world-writable, world-readable, +r/+w/+x, -r/-w/-x or an octal mode", v)
+}
+
+// cutComparison splits a clause value like >=10M into the comparison and what
+// it compares with, "" for a value without one
+func cutComparison(v string) (op, rest string) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(v, op); ok {
+			return op, rest
+		}
+	}
+	return "", v
+}
+
+// compared reports whether c, a cmp.Compare of a value with the clause's,
+// satisfies op, equality for none
+func compared(op string, c int) bool {
+	switch op {
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return c == 0
+}
+
+// sizeFilter compares the size of files, leaving directories out, with a
+// size like 10M: K, M, G and T count in 1024s like find and du, KB, MB and so
+// on in 1000s, and KiB, MiB and so on in 1024s again
+func sizeFilter(v string) (filter, error) {
+	op, val := cutComparison(v)
+	digits := strings.IndexFunc(val, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if digits < 0 {
+		digits = len(val)
+	}
+	n, err := strconv.ParseFloat(val[:digits], 64)
+	unit, known := sizeUnits[strings.ToUpper(val[digits:])]
+	if err != nil || !known {
+		return filter{}, fmt.Errorf("size:%s: expected a size like 10M, 500KB or 2GiB, after >, >=, <, <= or nothing for exactly", v)
+	}
+	size := int64(n * float64(unit))
+	return filter{needsStat: true, keep: func(_ string, info os.FileInfo) bool {
+		return !info.IsDir() && compared(op, cmp.Compare(info.Size(), size))
+	}}, nil
+}
+
+var sizeUnits = map[string]int64{
+	"": 1, "B": 1,
+	"K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40,
+	"KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12,
+	"KIB": 1 << 10, "MIB": 1 << 20, "GIB": 1 << 30, "TIB": 1 << 40,
+}
+
+// ageUnits are the units of an mtime: age
+var ageUnits = map[string]time.Duration{
+	"s": time.Second, "m": time.Minute, "h": time.Hour,
+	"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour,
+}
+
+// mtimeFilter compares when files were modified with an age, e.g. mtime:<7d
+// for the last week and mtime:>1y for over a year ago, or with a day, e.g.
+// mtime:>=2024-01-31. An age on its own means within it, a day on its own
+// that day.
+func mtimeFilter(v string, now time.Time) (filter, error) {
+	op, val := cutComparison(v)
+	if day, err := time.ParseInLocation(time.DateOnly, val, time.Local); err == nil {
+		next := day.AddDate(0, 0, 1)
+		return filter{needsStat: true, keep: func(_ string, info os.FileInfo) bool {
+			t := info.ModTime()
+			switch op {
+			case "<":
+				return t.Before(day)
+			case "<=":
+				return t.Before(next)
+			case ">":
+				return !t.Before(next)
+			case ">=":
+				return !t.Before(day)
+			}
+			return !t.Before(day) && t.Before(next)
+		}}, nil
+	}
+	n, err := strconv.ParseFloat(strings.TrimRight(val, "smhdwy"), 64)
+	unit, known := ageUnits[strings.TrimLeft(val, "0123456789.")]
+	if err != nil || !known {
+		return filter{}, fmt.Errorf("mtime:%s: expected an age like 7d, 3h or 2w, or a day like 2024-01-31, after >, >=, <, <= or nothing", v)
+	}
+	age := time.Duration(n * float64(unit))
+	if op == "" || op == "=" { // no file is exactly that old
+		op = "<="
+	}
+	return filter{needsStat: true, keep: func(_ string, info os.FileInfo) bool {
+		return compared(op, cmp.Compare(now.Sub(info.ModTime()), age))
+	}}, nil
+}
+
+var userNames sync.Map // uid -> user name, lookups hit /etc/passwd or NSS every time otherwise
+
+func userName(uid string) string {
+	if name, ok := userNames.Load(uid); ok {
+		return name.(string)
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	userNames.Store(uid, name)
+	return name
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuerySyntax(t *testing.T) {
+	tests := []struct {
+		input    string
+		alts     [][]string
+		excludes []string
+		fallback bool
+	}{
+		{"foo", [][]string{{"foo"}}, nil, false},
+		{"foo bar", [][]string{{"foo", "bar"}}, nil, false},
+		{"foo | bar baz", [][]string{{"foo"}, {"bar", "baz"}}, nil, false},
+		{"| foo |", [][]string{{"foo"}}, nil, false},
+		{"foo !tmp !cache", [][]string{{"foo"}}, []string{"tmp", "cache"}, false},
+		{`"my docs" !"old files"`, [][]string{{"my docs"}}, []string{"old files"}, false},
+		{"!", [][]string{{"!"}}, nil, false},
+		{"!tmp", [][]string{{matchAll}}, []string{"tmp"}, true},
+		{"name:report foo", [][]string{{"report", "foo"}}, nil, false},
+		{"ext:pdf", [][]string{{matchAll}}, nil, true},
+		{"parent:/etc/", [][]string{{"/etc/"}}, nil, true},
+		{"parent:/etc foo", [][]string{{"foo"}}, nil, false},
+		{"", nil, nil, true},
+	}
+	for _, tt := range tests {
+		q, err := parseQuery(tt.input, defaultFileTypes())
+		if err != nil {
+			t.Errorf("parseQuery(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(q.alts, tt.alts) || !reflect.DeepEqual(q.excludes, tt.excludes) || q.fallback != tt.fallback {
+			t.Errorf("parseQuery(%q) = alts %q, excludes %q, fallback %v, want %q, %q, %v",
+				tt.input, q.alts, q.excludes, q.fallback, tt.alts, tt.excludes, tt.fallback)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, input := range []string{
+		"name:", "content:", "owner:", "type:", "type:nope",
+		"ext:", "ext:,", "ext:pdf ext:,",
+		"size:", "size:10Q", "size:>", "size:1.2.3M",
+		"mtime:", "mtime:7x", "mtime:<d", "mtime:2024-13-01",
+		"perm:", "perm:+q", "perm:888", "perm:12345",
+		"depth:", "depth:-1", "depth:two",
+		"parent:", "parent:etc",
+		"content:invoice",
+	} {
+		if _, err := parseQuery(input, defaultFileTypes()); err == nil {
+			t.Errorf("parseQuery(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+// clauseTree makes a few files with known sizes, times and modes under a
+// temporary directory and returns it with every path in it worth checking
+func clauseTree(t *testing.T) (root string, paths []string) {
+	t.Helper()
+	root = t.TempDir()
+	now := time.Now()
+	for _, f := range []struct {
+		name string
+		size int
+		age  time.Duration
+		mode os.FileMode
+	}{
+		{"docs/report.pdf", 2048, 48 * time.Hour, 0o644},
+		{"docs/notes.txt", 10, 30 * 24 * time.Hour, 0o600},
+		{"bin/run.sh", 100, time.Hour, 0o755},
+		{"pics/cat.PNG", 5000, 400 * 24 * time.Hour, 0o644},
+	} {
+		path := filepath.Join(root, f.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, f.size), f.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, f.mode); err != nil { // past the umask
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now, now.Add(-f.age)); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	docs := filepath.Join(root, "docs")
+	if err := os.Chmod(docs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(docs, now, now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	return root, append(paths, docs)
+}
+
+func TestParseQueryClauses(t *testing.T) {
+	root, paths := clauseTree(t)
+	vars := strings.NewReplacer(
+		"$root", root,
+		"$uid", strconv.Itoa(os.Getuid()),
+		"$month", time.Now().Add(-30*24*time.Hour).Format(time.DateOnly),
+	)
+	all := []string{"bin/run.sh", "docs", "docs/notes.txt", "docs/report.pdf", "pics/cat.PNG"}
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"size:>1K", []string{"docs/report.pdf", "pics/cat.PNG"}},
+		{"size:<=100", []string{"bin/run.sh", "docs/notes.txt"}},
+		{"size:2K", []string{"docs/report.pdf"}},
+		{"size:>2KB", []string{"docs/report.pdf", "pics/cat.PNG"}},
+		{"size:>=2kib size:<3k", []string{"docs/report.pdf"}},
+		{"size:0.5M", nil},
+
+		{"mtime:<7d", []string{"bin/run.sh", "docs", "docs/report.pdf"}},
+		{"mtime:3h", []string{"bin/run.sh", "docs"}},
+		{"mtime:>1y", []string{"pics/cat.PNG"}},
+		{"mtime:>=1w mtime:<=5w", []string{"docs/notes.txt"}},
+		{"mtime:$month", []string{"docs/notes.txt"}},
+		{"mtime:<$month", []string{"pics/cat.PNG"}},
+		{"mtime:>$month", []string{"bin/run.sh", "docs", "docs/report.pdf"}},
+
+		{"ext:pdf", []string{"docs/report.pdf"}},
+		{"ext:.png,txt", []string{"docs/notes.txt", "pics/cat.PNG"}},
+		{"ext:pdf ext:sh", []string{"bin/run.sh", "docs/report.pdf"}},
+		{"ext:gz", nil},
+
+		{"type:images", []string{"pics/cat.PNG"}},
+		{"type:documents", []string{"docs/notes.txt", "docs/report.pdf"}},
+		{"type:dir", []string{"docs"}},
+		{"type:file", []string{"bin/run.sh", "docs/notes.txt", "docs/report.pdf", "pics/cat.PNG"}},
+		{"type:images type:dir", []string{"docs", "pics/cat.PNG"}},
+
+		{"owner:$uid", all},
+		{"owner:4000000000", nil},
+
+		{"perm:+x", []string{"bin/run.sh", "docs"}},
+		{"perm:-w", nil},
+		{"perm:600", []string{"docs/notes.txt"}},
+		{"perm:0644", []string{"docs/report.pdf", "pics/cat.PNG"}},
+		{"perm:world-readable", []string{"bin/run.sh", "docs", "docs/report.pdf", "pics/cat.PNG"}},
+		{"perm:suid", nil},
+
+		{"parent:$root/docs", []string{"docs/notes.txt", "docs/report.pdf"}},
+		{"parent:$root/docs/", []string{"docs/notes.txt", "docs/report.pdf"}},
+		{"parent:$root", []string{"docs"}},
+
+		{"type:file size:<1K perm:+x", []string{"bin/run.sh"}},
+		{"ext:pdf,txt mtime:<7d", []string{"docs/report.pdf"}},
+	}
+	for _, tt := range tests {
+		input := vars.Replace(tt.input)
+		q, err := parseQuery(input, defaultFileTypes())
+		if err != nil {
+			t.Errorf("parseQuery(%q): %v", input, err)
+			continue
+		}
+		var got []string
+		for _, path := range paths {
+			if _, ok := q.keep(path); ok {
+				rel, _ := filepath.Rel(root, path)
+				got = append(got, rel)
+			}
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s kept %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDepthClause(t *testing.T) {
+	root, paths := clauseTree(t)
+	all := []string{"bin/run.sh", "docs", "docs/notes.txt", "docs/report.pdf", "pics/cat.PNG"}
+	scoped := model{root: root, profile: -1}
+	prefixed := model{prefix: newPrefixInput(), profile: -1}
+	prefixed.prefix.SetValue(root + "/docs/")
+	tests := []struct {
+		m     model
+		input string
+		want  []string
+	}{
+		// counted from the root results are kept under
+		{scoped, "depth:0", nil},
+		{scoped, "depth:1", []string{"docs"}},
+		{scoped, "depth:2", all},
+		{scoped, "depth:1 ext:pdf", nil},
+		{scoped, "docs depth:1", []string{"docs"}},
+
+		// with no root, from the directory the path prefix names
+		{prefixed, "depth:0", nil},
+		{prefixed, "depth:1", []string{"docs/notes.txt", "docs/report.pdf"}},
+
+		// and with neither, from /
+		{model{profile: -1}, "depth:" + strconv.Itoa(pathDepth(root)+1), []string{"docs"}},
+		{model{profile: -1}, "depth:" + strconv.Itoa(pathDepth(root)+2), all},
+	}
+	for _, tt := range tests {
+		q, err := tt.m.prepareQuery(tt.input)
+		if err != nil {
+			t.Errorf("prepareQuery(%q): %v", tt.input, err)
+			continue
+		}
+		var got []string
+		for _, path := range paths {
+			if _, ok := q.keep(path); ok {
+				rel, _ := filepath.Rel(root, path)
+				got = append(got, rel)
+			}
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s from %s kept %q, want %q", tt.input, tt.m.depthBase(), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// recallKey steps through the query history with the arrow keys, like a
+// shell, while the input is empty or still holds the query last recalled.
+// Otherwise the arrows are left to move through the results.
+func (m *model) recallKey(msg tea.KeyMsg) bool {
+	if m.tableFocused || msg.Type != tea.KeyUp && msg.Type != tea.KeyDown {
+		return false
+	}
+	value := m.textInput.Value()
+	if m.recalled > len(m.queryHistory) || m.recalled > 0 && value != m.queryHistory[m.recalled-1] {
+		m.recalled = 0 // edited since, or the history changed under it
+	}
+	if value != "" && m.recalled == 0 {
+		return false
+	}
+	switch {
+	case msg.Type == tea.KeyUp && m.recalled < len(m.queryHistory):
+		m.recalled++
+	case msg.Type == tea.KeyDown && m.recalled > 0:
+		m.recalled--
+	default:
+		return true // nothing older or newer, but don't move the results either
+	}
+	query := ""
+	if m.recalled > 0 {
+		query = m.queryHistory[m.recalled-1]
+	}
+	m.textInput.SetValue(query)
+	m.textInput.CursorEnd()
+	m.suggest = suggestState{query: query, cursor: -1, hidden: true}
+	return true
+}
+
+// pickHistoryMsg puts a query picked from the history back in the input
+type pickHistoryMsg struct {
+	query string
+}
+
+// forgetQueryMsg drops a query from the history
+type forgetQueryMsg struct {
+	query string
+}
+
+// historyDialog lists the past queries, newest first, filtered and ranked by
+// the same fuzzy matching as results as the user types
+type historyDialog struct {
+	history []string
+	shown   []string
+	cursor  int
+	filter  textinput.Model
+}
+
+func newHistoryDialog(history []string) historyDialog {
+	ti := textinput.New()
+	ti.Placeholder = "Filter past queries..."
+	ti.Width = 40
+	ti.Focus()
+	return historyDialog{history: history, shown: history, filter: ti}
+}
+
+func (d historyDialog) Update(msg tea.Msg) (dialog, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(k, searchUp):
+			d.cursor = max(d.cursor-1, 0)
+			return d, nil
+		case key.Matches(k, searchDown):
+			d.cursor = max(min(d.cursor+1, len(d.shown)-1), 0)
+			return d, nil
+		case key.Matches(k, searchOpen):
+			if d.cursor >= len(d.shown) {
+				return d, nil
+			}
+			q := d.shown[d.cursor]
+			return d, tea.Batch(closeTopDialog, func() tea.Msg { return pickHistoryMsg{q} })
+		case key.Matches(k, searchDelete):
+			if d.cursor >= len(d.shown) {
+				return d, nil
+			}
+			q := d.shown[d.cursor]
+			d.history = slices.DeleteFunc(slices.Clone(d.history), func(h string) bool { return h == q })
+			d.refilter()
+			return d, func() tea.Msg { return forgetQueryMsg{q} }
+		}
+	}
+	var cmd tea.Cmd
+	d.filter, cmd = d.filter.Update(msg)
+	d.refilter()
+	return d, cmd
+}
+
+// refilter ranks the queries fuzzily matching the filter, best first
+func (d *historyDialog) refilter() {
+	term := strings.ToLower(strings.TrimSpace(d.filter.Value()))
+	type ranked struct {
+		query string
+		score int
+	}
+	var matches []ranked
+	for _, q := range d.history {
+		if score, ok := fuzzyScore(strings.ToLower(q), term); ok {
+			matches = append(matches, ranked{q, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b ranked) int { return cmp.Compare(b.score, a.score) }) // newest first while the filter is empty
+	d.shown = nil
+	for _, r := range matches {
+		d.shown = append(d.shown, r.query)
+	}
+	d.cursor = max(min(d.cursor, len(d.shown)-1), 0)
+}
+
+// historyShown is how many queries the dialog lists at once
+const historyShown = 15
+
+func (d historyDialog) View() string {
+	var b strings.Builder
+	b.WriteString(dialogTitleStyle.Render("Query history") + "\n\n" + d.filter.View() + "\n\n")
+	if len(d.history) == 0 {
+		b.WriteString(settingsDimStyle.Render("No queries yet, they're kept once their results are used") + "\n")
+	}
+	first := max(min(d.cursor-historyShown/2, len(d.shown)-historyShown), 0)
+	for i := first; i < min(first+historyShown, len(d.shown)); i++ {
+		if i == d.cursor {
+			b.WriteString(settingsCursorStyle.Render("› "+truncateRight(d.shown[i], 60)) + "\n")
+		} else {
+			b.WriteString("  " + truncateRight(d.shown[i], 60) + "\n")
+		}
+	}
+	if hidden := len(d.shown) - min(historyShown, len(d.shown)); hidden > 0 {
+		b.WriteString(settingsDimStyle.Render(fmt.Sprintf("  %d more", hidden)) + "\n")
+	}
+	return b.String() + "\n" + help.New().ShortHelpView([]key.Binding{searchUp, searchOpen, searchDelete, closeDialog})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newRefineInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "narrow these results"
+	ti.CharLimit = 128
+	return ti
+}
+
+// refineKey handles / while the table has focus, which opens a second input
+// that narrows the loaded rows without running plocate again. Enter keeps the
+// filter and goes back to the table, esc drops it.
+func (m *model) refineKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	if !m.refine.Focused() {
+		if m.tableFocused && key.Matches(msg, m.keys.Refine) { // never part of a file name, so no clash with type-ahead
+			return true, m.refine.Focus()
+		}
+		return false, nil
+	}
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.refine.Blur()
+		return true, nil
+	case tea.KeyEsc:
+		m.clearRefine()
+		return true, nil
+	}
+	before := m.refine.Value()
+	m.refine, cmd = m.refine.Update(msg)
+	if m.refine.Value() != before {
+		m.refreshRows()
+		m.table.SetCursor(0)
+	}
+	return true, cmd
+}
+
+func (m *model) clearRefine() {
+	m.refine.Blur()
+	if m.refine.Value() != "" {
+		m.refine.SetValue("")
+		m.refreshRows()
+	}
+}
+
+// refined keeps the rows whose path contains every word of the refine input, ignoring case
+func (m model) refined(rows []table.Row) []table.Row {
+	words := strings.Fields(strings.ToLower(m.refine.Value()))
+	if len(words) == 0 {
+		return rows
+	}
+	var kept []table.Row
+	for _, row := range rows {
+		path := strings.ToLower(row[2])
+		if !slices.ContainsFunc(words, func(w string) bool { return !strings.Contains(path, w) }) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// registerOp is what the next letter does after m or '
+type registerOp int
+
+const (
+	registerNone registerOp = iota
+	registerSet
+	registerJump
+)
+
+// registerKey handles m<letter> and '<letter> while the results have focus, like
+// marks in vim (tab marks are the selection, these are only places to go back to). Registers remember the result rather than the line, so they
+// survive sorting and new searches that still find it. Neither key starts a
+// register while a type-ahead prefix is pending, so both can still be typed there.
+func (m *model) registerKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	if m.register == registerNone {
+		switch {
+		case m.typeAhead.prefix != "":
+		case key.Matches(msg, m.keys.SetRegister):
+			m.register = registerSet
+			return true, nil
+		case key.Matches(msg, m.keys.JumpRegister):
+			m.register = registerJump
+			return true, nil
+		}
+		return false, nil
+	}
+
+	op := m.register
+	m.register = registerNone
+	r := msg.Runes
+	if msg.Type != tea.KeyRunes || len(r) != 1 || !unicode.IsLetter(r[0]) {
+		return msg.Type == tea.KeyEsc, nil // esc only cancels, anything else acts as usual
+	}
+	name := r[0]
+	switch op {
+	case registerSet:
+		row := m.selectedRow()
+		if row == nil {
+			return true, nil
+		}
+		if m.registers == nil {
+			m.registers = map[rune]rowID{}
+		}
+		m.registers[name] = idOf(row)
+		return true, notify(toastInfo, fmt.Sprintf("Remembered this row as %c", name))
+	default:
+		id, ok := m.registers[name]
+		if !ok {
+			return true, notify(toastWarn, fmt.Sprintf("No row remembered as %c", name))
+		}
+		i := slices.IndexFunc(m.rows, func(row table.Row) bool { return idOf(row) == id })
+		if i < 0 {
+			return true, notify(toastWarn, fmt.Sprintf("Row %c isn't in these results", name))
+		}
+		m.table.SetCursor(i)
+		return true, nil
+	}
+}
+
+// registerStatus prompts for the letter once m or ' has been pressed
+func (m model) registerStatus() string {
+	switch m.register {
+	case registerSet:
+		return "remember row as: press a letter"
+	case registerJump:
+		return "go back to row: press a letter"
+	}
+	return ""
+}
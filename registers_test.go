@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRegistersSkipPane(t *testing.T) {
+	m := drive(fixtureModel(t), append([]tea.Msg{tea.WindowSizeMsg{Width: 120, Height: 32}}, typed("docs")...)...)
+	m = drive(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}, Alt: true}, tea.KeyMsg{Type: tea.KeyEsc}, tea.KeyMsg{Type: tea.KeyTab})
+	if !m.pane.focused {
+		t.Fatal("tab didn't move to the folder pane")
+	}
+	m = drive(m, typed("ma")...)
+	if len(m.registers) != 0 {
+		t.Errorf("m a in the pane saved %v", m.registers)
+	}
+
+	m = drive(m, tea.KeyMsg{Type: tea.KeyTab})
+	m = drive(m, typed("ma")...)
+	if id, ok := m.registers['a']; !ok || id != idOf(m.selectedRow()) {
+		t.Errorf("m a in the results saved %v, want the row under the cursor", m.registers)
+	}
+}
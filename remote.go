@@ -0,0 +1,155 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// localActions read or write the files themselves, which with -remote would
+// be this machine's files of the same name, so they're refused there
+var localActions = map[string]bool{
+	"update_db":   true,
+	"checksum":    true,
+	"diff":        true,
+	"send":        true,
+	"share":       true,
+	"batch":       true,
+	"permissions": true,
+	"pipe":        true, // the command runs here, on paths named after the remote's
+	"pane":        true, // lists this machine's directories
+	"live":        true, // fd and rg walk this machine
+	"content":     true,
+	"elevate":     true, // pkexec runs here
+}
+
+// sshArgs runs command on host over one shared connection, so searches and
+// stats don't each log in again. Only the first, made before the TUI starts,
+// may ask for a password; later ones fail rather than prompt over the screen.
+func sshArgs(host string, interactive bool, command ...string) []string {
+	socket := filepath.Join(cmp.Or(os.Getenv("XDG_RUNTIME_DIR"), os.TempDir()), "gocate-ssh-%C")
+	args := []string{"-o", "ControlMaster=auto", "-o", "ControlPersist=5m", "-o", "ControlPath=" + socket}
+	if !interactive {
+		args = append(args, "-o", "BatchMode=yes")
+	}
+	quoted := make([]string, len(command))
+	for i, c := range command {
+		quoted[i] = shellQuote(c) // ssh hands the remote shell one string
+	}
+	return append(args, "--", host, strings.Join(quoted, " "))
+}
+
+// remoteDetect picks a locate on the remote host the way detectSearcher does here
+const remoteDetect = `for c in plocate mlocate; do command -v $c >/dev/null && { echo $c; exit; }; done
+command -v locate >/dev/null || exit 0
+locate --version 2>/dev/null | grep -q findutils && echo findutils || echo locate`
+
+// detectRemoteSearcher logs in to host, asking for a password on the terminal
+// if it has to, and finds which locate it has
+func detectRemoteSearcher(host string) (locateSearcher, error) {
+	cmd := exec.Command("ssh", sshArgs(host, true, "sh", "-c", remoteDetect)...)
+	cmd.Stdin, cmd.Stderr = os.Stdin, os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return locateSearcher{}, fmt.Errorf("couldn't connect to %s: %w", host, err)
+	}
+	var l locateSearcher
+	switch strings.TrimSpace(string(out)) {
+	case "plocate":
+		l = plocate
+	case "mlocate":
+		l = mlocate
+	case "findutils":
+		l = gnuLocate
+	case "locate":
+		l = locateSearcher{command: "locate", database: mlocate.database}
+	default:
+		return l, fmt.Errorf("no plocate, mlocate or locate on %s", host)
+	}
+	l.host = host
+	return l, nil
+}
+
+// useRemote points searches and stats at l's host for the rest of the run.
+// Live and content search would walk this machine, so there are none.
+func useRemote(l locateSearcher) {
+	backend = l
+	liveBackend, contentBackend = nil, nil
+	statPath = func(path string) (os.FileInfo, error) {
+		return remoteStat(l.host, path)
+	}
+}
+
+// remoteStat is os.Stat for a path on host, by GNU stat, following symlinks
+// like os.Stat does
+func remoteStat(host, path string) (os.FileInfo, error) {
+	out, err := exec.Command("ssh", sshArgs(host, false, "stat", "-L", "--printf", statFormat, "--", path)...).Output()
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: host + ":" + path, Err: err}
+	}
+	return parseStat(path, string(out))
+}
+
+// unixMode turns a raw st_mode, as stat %f prints it, into an fs.FileMode
+func unixMode(raw uint32) fs.FileMode {
+	mode := fs.FileMode(raw & 0o777)
+	switch raw & 0o170000 {
+	case 0o040000:
+		mode |= fs.ModeDir
+	case 0o120000:
+		mode |= fs.ModeSymlink
+	case 0o010000:
+		mode |= fs.ModeNamedPipe
+	case 0o140000:
+		mode |= fs.ModeSocket
+	case 0o020000:
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case 0o060000:
+		mode |= fs.ModeDevice
+	}
+	return mode
+}
+
+// remoteInfo is the os.FileInfo GNU stat printed, for a file on the remote
+// host or one read through pkexec
+type remoteInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i remoteInfo) Name() string       { return i.name }
+func (i remoteInfo) Size() int64        { return i.size }
+func (i remoteInfo) Mode() fs.FileMode  { return i.mode }
+func (i remoteInfo) ModTime() time.Time { return i.modTime }
+func (i remoteInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i remoteInfo) Sys() any           { return nil }
+
+// copyTarget is what copying path puts on the clipboard: the path, or with
+// -remote host:path as scp and rsync take it
+func (m model) copyTarget(path string) string {
+	if m.remote == "" {
+		return path
+	}
+	return m.remote + ":" + path
+}
+
+// openTarget is what the opener is given for path: the path, or with -remote
+// an sftp:// URL file managers can browse
+func (m model) openTarget(path string) string {
+	if m.remote == "" {
+		return path
+	}
+	u := url.URL{Scheme: "sftp", Host: m.remote, Path: path}
+	if user, host, ok := strings.Cut(m.remote, "@"); ok {
+		u.User, u.Host = url.User(user), host
+	}
+	return u.String()
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/mattn/go-runewidth"
+)
+
+type sortMode int
+
+const (
+	sortNatural sortMode = iota // plocate's own order
+	sortDepth                   // shallowest first, siblings grouped together
+	sortSize                    // largest first, rows not statted yet last
+	sortDevice                  // grouped by the device they're on, biggest device first
+	sortType                    // grouped by file type, the type with most results first
+	sortModeCount
+)
+
+var sortModeNames = map[sortMode]string{
+	sortNatural: "plocate order",
+	sortDepth:   "depth",
+	sortSize:    "size",
+	sortDevice:  "device",
+	sortType:    "type",
+}
+
+// sortName names a sort mode for the status bar and toasts. The natural order
+// is whatever order the config ranks every match in.
+func (m model) sortName(mode sortMode) string {
+	if mode == sortNatural {
+		return orderDescriptions[parseOrder(m.cfg.Order)]
+	}
+	return sortModeNames[mode]
+}
+
+// rowID identifies a result independent of where sorting or filtering put it
+type rowID string
+
+func idOf(row table.Row) rowID {
+	return rowID(row[2])
+}
+
+// setResults replaces the loaded results, keeping them in plocate's order so sorting can be undone
+func (m *model) setResults(rows []table.Row) {
+	numbers := numberWidth(len(m.results))
+	m.results = rows
+	if m.compact && numberWidth(len(rows)) != numbers {
+		m.resizeColumns() // the row numbers need a wider or narrower column
+		return
+	}
+	m.refreshRows()
+}
+
+// updateRow applies f to the result with the given id, wherever it currently sits,
+// and reports whether it was still loaded
+func (m *model) updateRow(id rowID, f func(table.Row)) bool {
+	for _, row := range m.results {
+		if idOf(row) == id {
+			f(row)
+			m.refreshRows()
+			return true
+		}
+	}
+	return false
+}
+
+// removeRow drops a result that turned out not to exist
+func (m *model) removeRow(id rowID) {
+	m.results = slices.DeleteFunc(slices.Clone(m.results), func(r table.Row) bool { return idOf(r) == id })
+	m.refreshRows()
+}
+
+// toggleMark adds or removes a result from the marked set, which outlives the search that found it
+func (m *model) toggleMark(id rowID) {
+	if m.marked[id] {
+		delete(m.marked, id)
+	} else {
+		if m.marked == nil {
+			m.marked = map[rowID]bool{}
+		}
+		m.marked[id] = true
+	}
+	m.refreshRows()
+}
+
+// markedPaths lists the marked results in a stable order
+func (m model) markedPaths() []string {
+	paths := make([]string, 0, len(m.marked))
+	for id := range m.marked {
+		paths = append(paths, string(id))
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// selection is what actions on files work on: the marked results, or else the one under the cursor
+func (m model) selection() []string {
+	if len(m.marked) > 0 {
+		return m.markedPaths()
+	}
+	if row := m.selectedRow(); row != nil {
+		return []string{row[2]}
+	}
+	return nil
+}
+
+// refreshRows rebuilds the table from the results in the current sort order.
+// m.rows keeps the real cells, the table only gets what is drawn. The cursor
+// follows the selected result rather than staying on the same line.
+func (m *model) refreshRows() {
+	var selected rowID
+	if row := m.selectedRow(); row != nil {
+		selected = idOf(row)
+	}
+	m.rows = m.refined(slices.Clone(m.results))
+	switch m.sortMode {
+	case sortDepth:
+		slices.SortStableFunc(m.rows, func(a, b table.Row) int {
+			return cmp.Or(cmp.Compare(pathDepth(a[2]), pathDepth(b[2])), strings.Compare(a[2], b[2]))
+		})
+	case sortSize:
+		size := func(r table.Row) int64 {
+			if info := m.infos[idOf(r)]; info != nil && !info.IsDir() {
+				return info.Size()
+			}
+			return -1
+		}
+		slices.SortStableFunc(m.rows, func(a, b table.Row) int { return cmp.Compare(size(b), size(a)) })
+	case sortDevice:
+		rank := map[string]int{}
+		for i, t := range m.deviceTotals() {
+			rank[t.name] = i
+		}
+		group := func(r table.Row) int {
+			if dev, ok := deviceOf(m.infos[idOf(r)]); ok {
+				return rank[deviceName(dev)]
+			}
+			return len(rank)
+		}
+		slices.SortStableFunc(m.rows, func(a, b table.Row) int { return cmp.Compare(group(a), group(b)) })
+	case sortType:
+		rank := map[string]int{}
+		for i, t := range m.typeTotals() {
+			rank[t.name] = i
+		}
+		group := func(r table.Row) int {
+			if t := m.types.typeOf(r[2]); t != nil {
+				return rank[t.Name]
+			}
+			return rank["other"]
+		}
+		slices.SortStableFunc(m.rows, func(a, b table.Row) int { return cmp.Compare(group(a), group(b)) })
+	}
+
+	cols := m.table.Columns()
+	display := make([]table.Row, len(m.rows))
+	paths := make([]string, len(m.rows))
+	for i, row := range m.rows {
+		paths[i] = m.displayPath(row[2])
+		display[i] = append(slices.Clone(row[:5]), sourceOf(row)) // the database column, empty for most
+		if m.marked[idOf(row)] {
+			display[i][0] = "✔"
+		}
+		if m.compact {
+			display[i][0] = fmt.Sprintf("%*d %s", numberWidth(len(m.rows)), i+1, display[i][0])
+		}
+		display[i][1] = cleanCell(accessBadge(m.infos[idOf(row)]) + row[1])
+		// whole paths even in depth order, tableView dims what they share with the row above
+		display[i][2] = cleanCell(paths[i])
+		if len(cols) == len(display[i]) { // fit cells ourselves, keeping the end of paths where the names are
+			display[i][1] = truncateRight(display[i][1], cols[1].Width)
+			display[i][2] = truncateLeft(display[i][2], cols[2].Width)
+			display[i][5] = truncateLeft(display[i][5], cols[5].Width)
+		}
+	}
+	m.table.SetRows(display)
+	if i := slices.IndexFunc(m.rows, func(r table.Row) bool { return idOf(r) == selected }); i >= 0 {
+		m.table.SetCursor(i)
+	}
+}
+
+// cleanCell makes a file name safe to draw in one table cell: control characters
+// (newlines are legal in names) would break the row, and U+FE0F makes terminals
+// and runewidth disagree on how wide an emoji is
+func cleanCell(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\uFE0F':
+			return -1
+		case unicode.IsControl(r):
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// truncateRight cuts s to w terminal cells, counting wide CJK and emoji as two
+func truncateRight(s string, w int) string {
+	return runewidth.Truncate(s, w, "…")
+}
+
+// truncateLeft cuts from the front instead, so the end of a long path stays visible
+func truncateLeft(s string, w int) string {
+	if w <= 0 || runewidth.StringWidth(s) <= w {
+		return s
+	}
+	runes := []rune(s)
+	width, i := 1, len(runes) // 1 for the ellipsis
+	for i > 0 {
+		rw := runewidth.RuneWidth(runes[i-1])
+		if width+rw > w {
+			break
+		}
+		width += rw
+		i--
+	}
+	return "…" + string(runes[i:])
+}
+
+// displayPath shortens paths under $HOME to ~/... when the config asks for it
+func (m model) displayPath(path string) string {
+	if !m.cfg.HomePaths || m.home == "" {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, m.home); ok && (rest == "" || rest[0] == '/') {
+		return "~" + rest
+	}
+	return path
+}
+
+// groupedPrefix is how many cells of path, its directory and the slash after
+// it, repeat the row above's, 0 when they're in different directories
+func groupedPrefix(prev, path string) int {
+	dir := filepath.Dir(path)
+	if dir == "/" || dir != filepath.Dir(prev) {
+		return 0
+	}
+	return runewidth.StringWidth(cleanCell(dir)) + 1
+}
+
+// selectedRow is the result under the cursor, with its full path
+func (m model) selectedRow() table.Row {
+	if c := m.table.Cursor(); c >= 0 && c < len(m.rows) {
+		return m.rows[c]
+	}
+	return nil
+}
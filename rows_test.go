@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRefreshRowsTruncation(t *testing.T) {
+	path := "/home/ann/projects/some/deeply/nested/directory/tree/that/goes/on/and/on/quarterly-report.pdf"
+	m := model{table: table.New(), width: 80, profile: -1, pane: paneState{table: newPaneTable()}}
+	m.results = []table.Row{pendingRow(path, defaultFileTypes())}
+	m.resizeColumns()
+
+	cols, row := m.table.Columns(), m.table.Rows()[0]
+	if len(row) != len(cols) {
+		t.Fatalf("drawn row has %d cells for %d columns", len(row), len(cols))
+	}
+	// the path is cut from the front, keeping the file name at its end
+	if cell := row[2]; !strings.HasPrefix(cell, "…") || !strings.HasSuffix(cell, "/quarterly-report.pdf") {
+		t.Errorf("path cell = %q, want the front cut and the file name kept", cell)
+	}
+	if w := len([]rune(row[2])); w > cols[2].Width {
+		t.Errorf("path cell is %d wide, the column %d", w, cols[2].Width)
+	}
+	if cell := row[1]; !strings.HasPrefix(cell, "quarterly") || len([]rune(cell)) > cols[1].Width {
+		t.Errorf("name cell = %q, want the start of the name in %d cells", cell, cols[1].Width)
+	}
+	if m.rows[0][2] != path {
+		t.Errorf("m.rows holds %q, want the whole path %q", m.rows[0][2], path)
+	}
+}
+
+func TestGroupedCell(t *testing.T) {
+	path := "/home/ann/docs/report.pdf"
+	if n := groupedPrefix("/home/ann/docs/notes.txt", path); n != len("/home/ann/docs/") {
+		t.Errorf("groupedPrefix of siblings = %d", n)
+	}
+	if n := groupedPrefix("/home/ann/notes.txt", path); n != 0 {
+		t.Errorf("groupedPrefix across directories = %d", n)
+	}
+
+	n := groupedPrefix("/home/ann/docs/notes.txt", path)
+	for _, tc := range []struct {
+		cell, dim string
+	}{
+		{path, "/home/ann/docs/"},
+		{truncateLeft(path, 14), "…cs/"}, // the front cut, the rest of the directory still dimmed
+		{truncateLeft(path, 10), ""},     // only the name left, part of it under the "…"
+	} {
+		got := groupedCell(tc.cell, path, n)
+		if ansi.Strip(got) != tc.cell {
+			t.Errorf("groupedCell(%q) changed the text to %q", tc.cell, ansi.Strip(got))
+		}
+		want := tc.cell
+		if tc.dim != "" {
+			want = groupedDirOn + tc.dim + groupedDirOff + strings.TrimPrefix(tc.cell, tc.dim)
+		}
+		if got != want {
+			t.Errorf("groupedCell(%q) = %q, want %q", tc.cell, got, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/termenv"
+)
+
+// The table styles every row alike, so zebra stripes, spacing and the
+// separators between directories are drawn over what it renders, each line
+// under the header matched back to its row through firstVisibleRow.
+
+const (
+	densityDense    = "dense"    // one space between columns instead of two
+	densitySpacious = "spacious" // a blank line between rows
+)
+
+// densityNames are the row densities in the order the settings go through them, "" being normal
+var densityNames = []string{densityDense, "", densitySpacious}
+
+// tableHeaderLines is the header and the rule under it
+const tableHeaderLines = 2
+
+var (
+	stripeStyle    = lipgloss.NewStyle().Background(lipgloss.AdaptiveColor{Light: "254", Dark: "235"})
+	groupRuleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	groupedDirOn, groupedDirOff = ansi.Style{}.Faint().String(), ansi.Style{}.NormalIntensity().String()
+)
+
+// tableLines is how many lines the table has on screen
+func (m model) tableLines() int {
+	return max(m.height-9, 1)
+}
+
+// resizeTable fits the table in the lines it has, half as many rows with spacious ones
+func (m *model) resizeTable() {
+	height := m.tableLines()
+	if m.cfg.Density == densitySpacious {
+		height = tableHeaderLines + max((height-tableHeaderLines+1)/2, 1)
+	}
+	m.table.SetHeight(height)
+	m.visibleRows = height
+}
+
+// densityStyles narrows the cell padding for dense rows
+func densityStyles(s table.Styles, density string) table.Styles {
+	if density == densityDense {
+		s.Header = s.Header.Padding(0, 1, 0, 0)
+		s.Cell = s.Cell.Padding(0, 1, 0, 0)
+	}
+	return s
+}
+
+// cellPadding is how many columns the padding around each cell takes
+func cellPadding(density string) int {
+	if density == densityDense {
+		return 1
+	}
+	return 2
+}
+
+// groupedCell dims the first prefix cells of path in cell, its drawn and
+// maybe front-truncated form, so a run of siblings in depth order reads as one
+// group with the names standing out underneath. Only SGR faint is used, whose
+// end leaves the stripe or underline drawn around the row alone.
+func groupedCell(cell, path string, prefix int) string {
+	// the cells cut from the front, less the one "…" took, which is dimmed with them
+	prefix -= runewidth.StringWidth(path) - runewidth.StringWidth(cell)
+	if prefix <= 0 {
+		return cell
+	}
+	return groupedDirOn + ansi.Truncate(cell, prefix, "") + groupedDirOff + ansi.TruncateLeft(cell, prefix, "")
+}
+
+// tableView is the table as configured: striped, spread out and with a
+// line where the directory changes, and with what a watch found highlighted.
+// Stripes are left out on 16-colour terminals, where a background behind
+// text is often unreadable.
+func (m model) tableView() string {
+	view := m.table.View()
+	spacious := m.cfg.Density == densitySpacious
+	zebra := m.cfg.ZebraRows && !basicColors()
+	grouped := m.sortMode == sortDepth && lipgloss.ColorProfile() != termenv.Ascii
+	if !zebra && !m.cfg.Separators && !spacious && len(m.watch.fresh) == 0 && !grouped {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+	if len(lines) <= tableHeaderLines {
+		return view
+	}
+	rows, cells := m.rows, m.table.Rows() // in the table's order, with whole paths and as drawn
+	first, cursor := m.firstVisibleRow(), m.table.Cursor()
+	out := lines[:tableHeaderLines:tableHeaderLines]
+	body := lines[tableHeaderLines:]
+	for i, line := range body {
+		r := first + i
+		if r >= len(rows) { // the blank lines under the last row
+			out = append(out, line)
+			continue
+		}
+		boundary := m.cfg.Separators && r+1 < len(rows) && filepath.Dir(rows[r][2]) != filepath.Dir(rows[r+1][2])
+		if r != cursor { // the selected row has its own style already
+			// dimming ends bold too, so fresh rows are left whole
+			if grouped && r > 0 && !m.watch.fresh[idOf(rows[r])] {
+				path := cleanCell(m.displayPath(rows[r][2]))
+				if n := groupedPrefix(m.displayPath(rows[r-1][2]), m.displayPath(rows[r][2])); n > 0 && cells[r][2] != "" {
+					line = strings.Replace(line, cells[r][2], groupedCell(cells[r][2], path, n), 1)
+				}
+			}
+			style, styled := lipgloss.NewStyle(), false
+			if zebra && r%2 == 1 {
+				style, styled = stripeStyle, true
+			}
+			if boundary && !spacious {
+				style, styled = style.Underline(true), true
+			}
+			if m.watch.fresh[idOf(rows[r])] {
+				style, styled = style.Inherit(freshStyle), true
+			}
+			if styled {
+				line = style.Render(line)
+			}
+		}
+		out = append(out, line)
+		if spacious && i < len(body)-1 && r+1 < len(rows) {
+			if boundary {
+				out = append(out, groupRuleStyle.Render(strings.Repeat("─", lipgloss.Width(line))))
+			} else {
+				out = append(out, "")
+			}
+		}
+	}
+	for len(out) < m.tableLines() && spacious {
+		out = append(out, "")
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setRootMsg is the directory typed into the scope prompt
+type setRootMsg struct {
+	dir string
+}
+
+// resolveRoot turns a typed directory, ~ included, into the absolute path
+// results are kept under, or "" for no scope
+func resolveRoot(dir, home string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" || dir == "/" {
+		return "", nil
+	}
+	dir, err := filepath.Abs(expandHome(dir, home))
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s isn't a directory", dir)
+	}
+	return dir, nil
+}
+
+// expandHome replaces a leading ~ with home, leaving ~user and the rest alone
+func expandHome(path, home string) string {
+	if rest, ok := strings.CutPrefix(path, "~"); ok && home != "" && (rest == "" || rest[0] == '/') {
+		return home + rest
+	}
+	return path
+}
+
+// rootFilter keeps root and whatever is below it
+func rootFilter(root string) filter {
+	return filter{keep: func(path string, _ os.FileInfo) bool {
+		return path == root || strings.HasPrefix(path, root+"/")
+	}}
+}
+
+// newRootDialog prompts for the directory to keep results under
+func (m model) newRootDialog() promptDialog {
+	d := newPromptDialog("Search under", "Leave empty to search everywhere", "Directory: ", m.displayRoot(), func(dir string) tea.Msg {
+		return setRootMsg{dir}
+	})
+	d.complete = pathCompleter(m.home, true)
+	return d
+}
+
+// setRoot scopes searches to dir and searches again
+func (m *model) setRoot(dir string) tea.Cmd {
+	root, err := resolveRoot(dir, m.home)
+	if err != nil {
+		return notify(toastError, err.Error())
+	}
+	m.root = root
+	m.lastQuery = ""
+	if root == "" {
+		return notify(toastInfo, "Searching everywhere")
+	}
+	return notify(toastInfo, "Searching under "+m.displayRoot())
+}
+
+// displayRoot is the scope as the user would type it, with ~ for home
+func (m model) displayRoot() string {
+	if rest, ok := strings.CutPrefix(m.root, m.home); ok && m.home != "" && m.root != "" && (rest == "" || rest[0] == '/') {
+		return "~" + rest
+	}
+	return m.root
+}
+
+// depthBase is what depth: counts from: the root results are kept under, or
+// else the directory the path prefix is in, or else /
+func (m model) depthBase() string {
+	if m.root != "" {
+		return m.root
+	}
+	if p := m.prefixPath(); p != "" {
+		if dir := prefixDir(p); filepath.IsAbs(dir) {
+			return dir
+		}
+	}
+	return "/"
+}
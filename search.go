@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// search starts a fresh plocate run, killing the previous one if it's still streaming
+func (m *model) search() tea.Cmd {
+	if m.cancelSearch != nil {
+		m.cancelSearch()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelSearch = cancel
+	input, limit := m.searchQuery, m.itemLimit
+	q, err := m.prepareQuery(input)
+	if err != nil {
+		return func() tea.Msg { return searchResultsMsg{query: input, limit: limit, err: err} }
+	}
+	if len(q.alts) == 0 { // only spaces
+		return func() tea.Msg { return searchResultsMsg{query: input, limit: limit, rows: []table.Row{}} }
+	}
+	run := func(q query) tea.Cmd { return runSearch(ctx, input, q, limit, m.siUnit, !m.compact) }
+	if q.fallback || m.content {
+		return run(q)
+	}
+	relax := []relaxation{ignoringCase}
+	if _, walking := backend.(walkSearcher); !walking && !m.live && m.caps.regex { // reading every name needs an index
+		relax = append(relax, misspelled)
+	}
+	return approximately(ctx, q, run, relax...)
+}
+
+// prepareQuery parses input and adds everything the current settings search
+// with: the backend's options and patterns, and the clauses of the profile, scope
+// and ignore list
+func (m model) prepareQuery(input string) (query, error) {
+	q, err := parseQuery(input, m.types)
+	if err != nil || len(q.alts) == 0 {
+		return q, err
+	}
+	checked := m.plocatePatterns(q.excludes)
+	switch {
+	case q.fallback:
+	case m.content: // what to look for inside files, given to rg as typed
+		if m.matchMode == matchRegex {
+			checked = append(checked, slices.Concat(q.alts...)...)
+		}
+	default:
+		switch m.matchMode {
+		case matchFuzzy:
+			q.score = m.fuzzyScorer(slices.Clone(q.alts))
+		case matchInitials: // plocate only finds the letters in order
+			q.filters = append(q.filters, initialsFilter(slices.Clone(q.alts)))
+		}
+		for i, alt := range q.alts {
+			q.alts[i] = m.plocatePatterns(alt)
+		}
+		checked = append(checked, slices.Concat(q.alts...)...)
+	}
+	for _, p := range checked {
+		if err := m.checkPattern(p); err != nil {
+			return q, err
+		}
+	}
+	if len(q.excludes) > 0 {
+		f, err := m.excludeFilter(q.excludes)
+		if err != nil {
+			return q, err
+		}
+		q.filters = append(q.filters, f)
+	}
+	q.opts, q.from, q.order = m.searchOpts(), backend, parseOrder(m.cfg.Order)
+	if m.live {
+		q.from = liveBackend
+	}
+	if m.content {
+		q.from = contentBackend
+		q.opts.regex, q.opts.basicRegex, q.opts.basename = m.matchMode == matchRegex, false, false
+	}
+	if p := m.activeProfile(); p != nil {
+		q.filters = append(q.filters, p.filters()...)
+	}
+	if m.root != "" {
+		q.filters = append(q.filters, rootFilter(m.root))
+	}
+	if p := m.prefixPath(); p != "" {
+		q.filters = append(q.filters, prefixFilter(p))
+		if dir := prefixDir(p); filepath.IsAbs(dir) && (q.opts.root == "" || strings.HasPrefix(dir, q.opts.root+"/")) {
+			q.opts.root = dir // fd and the walker needn't look anywhere else
+		}
+	}
+	if q.depth >= 0 {
+		q.filters = append(q.filters, depthFilter(m.depthBase(), q.depth))
+	}
+	if m.content && q.opts.root == "" {
+		q.opts.root = m.home // reading every file on the machine would take minutes
+	}
+	if len(m.cfg.Ignore) > 0 {
+		q.filters = append(q.filters, ignoreFilter(m.cfg.Ignore))
+	}
+	if len(q.contains) > 0 && m.remote != "" {
+		return q, fmt.Errorf("content: reads this machine's files, not %s's", m.remote)
+	}
+	if len(q.contains) > 0 { // last, so only files every other clause kept get read
+		q.filters = append(q.filters, containsFilter(q.contains, m.foldCase()))
+	}
+	return q, nil
+}
+
+const (
+	slowQuery       = 500 * time.Millisecond
+	slowQueryStreak = 3 // slow searches in a row before suggesting a narrower query
+)
+
+// trackLatency warns once when searches keep being slow, rather than on a single outlier
+func (m *model) trackLatency(elapsed time.Duration) tea.Cmd {
+	if elapsed < slowQuery {
+		m.slowStreak = 0
+		return nil
+	}
+	m.slowStreak++
+	if m.slowStreak != slowQueryStreak {
+		return nil
+	}
+	return notify(toastWarn, fmt.Sprintf("Searches are taking over %s, try a longer or more specific query", slowQuery))
+}
+
+func formatElapsed(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// runSearch reads a null-separated plocate stream per alternative, turning the
+// first limit entries into rows and counting the rest, so a plain query only
+// costs one process. Paths found by several alternatives count once. Entries
+// failing the query's clauses are neither shown nor counted. Without stat,
+// rows are never statted just to be displayed. With a score or an order,
+// every match is ranked and the best limit become rows instead of the first;
+// ordering by size or time stats every match to do so.
+func runSearch(ctx context.Context, query string, q query, limit int, siUnit, stat bool) tea.Cmd {
+	return func() tea.Msg {
+		start := time.Now()
+		rows, total := []table.Row{}, 0
+		var pending []string
+		infos := map[rowID]os.FileInfo{}
+		dirs := map[string]os.FileInfo{}
+		var ranked []scoredPath
+		compare := q.order.compare
+		if q.score != nil {
+			compare = byScore
+		}
+		addRow := func(path, source string, info os.FileInfo) {
+			if info == nil { // stat later, so the rows can be drawn right away
+				rows = append(rows, withSource(pendingRow(path, q.types), source))
+				if stat {
+					pending = append(pending, path)
+				}
+				return
+			}
+			rows = append(rows, withSource(buildRow(path, info, siUnit, q.types), source))
+			infos[rowID(path)] = withDir(path, info, dirs)
+		}
+		err := q.run(ctx, func(path, source string, info os.FileInfo) {
+			total++
+			switch {
+			case q.score != nil:
+				ranked = append(ranked, scoredPath{path: path, source: source, info: info, score: q.score(path)})
+			case q.order != orderNone:
+				if info == nil && q.order.needsStat() {
+					info, _ = statPath(path) // unstattable paths rank last
+				}
+				ranked = append(ranked, scoredPath{path: path, source: source, info: info})
+			default:
+				if total <= limit {
+					addRow(path, source, info)
+				}
+				return
+			}
+			if len(ranked) > 2*limit { // only ever hold on to the best so far
+				ranked = topScored(ranked, limit, compare)
+			}
+		})
+		if ctx.Err() != nil { // superseded by a newer search
+			return nil
+		}
+		if err != nil {
+			return searchResultsMsg{query: query, limit: limit, elapsed: time.Since(start), err: err}
+		}
+		for _, c := range topScored(ranked, limit, compare) {
+			addRow(c.path, c.source, c.info)
+		}
+		return searchResultsMsg{query: query, limit: limit, elapsed: time.Since(start), rows: rows, infos: infos, pending: pending, total: total}
+	}
+}
+
+// run streams every path matching q to found, one search per alternative,
+// with the database it's from and the stat the clauses took if any
+func (q query) run(ctx context.Context, found func(path, source string, info os.FileInfo)) error {
+	var seen map[string]bool
+	if len(q.alts) > 1 {
+		seen = map[string]bool{}
+	}
+	for _, patterns := range q.alts {
+		results, err := q.from.Search(ctx, patterns, q.opts)
+		if err != nil {
+			return err
+		}
+		for r := range results {
+			if r.err != nil {
+				return r.err
+			}
+			if seen != nil {
+				if seen[r.path] {
+					continue
+				}
+				seen[r.path] = true
+			}
+			if info, ok := q.keep(r.path); ok {
+				found(r.path, r.source, info)
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// rowStatMsg carries the full row for a result once its stat comes back
+type rowStatMsg struct {
+	id   rowID
+	row  table.Row
+	info os.FileInfo
+	err  error
+}
+
+// statRows stats each path as its own job, refining the icon and filling in size and time
+func statRows(jobs *jobManager, paths []string, siUnit bool, types fileTypes) tea.Cmd {
+	cmds := make([]tea.Cmd, len(paths))
+	for i, path := range paths {
+		cmds[i] = jobs.run(jobStat, path, func(context.Context) tea.Msg {
+			info, err := statPath(path)
+			if err != nil {
+				return rowStatMsg{id: rowID(path), err: err}
+			}
+			return rowStatMsg{id: rowID(path), row: buildRow(path, info, siUnit, types), info: withDir(path, info, nil)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// pendingRow is a best guess from the name alone, shown until the stat arrives
+func pendingRow(item string, types fileTypes) table.Row {
+	icon := extIcon(item, types)
+	if icon == "" {
+		icon = "📄"
+	}
+	return table.Row{icon, filepath.Base(item), item, "", ""}
+}
+
+// extIcon is the icon a file type gets regardless of its mode, or "" for none
+func extIcon(item string, types fileTypes) string {
+	if t := types.typeOf(item); t != nil {
+		return t.Icon
+	}
+	return ""
+}
+
+// scanNull is a bufio.SplitFunc for plocate -0 output
+func scanNull(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// buildRow turns a path and its stat into a table row
+func buildRow(item string, info os.FileInfo, siUnit bool, types fileTypes) table.Row {
+	icon, size, mod := "📄", "", ""
+	if info.IsDir() {
+		icon = "📂"
+	} else {
+		if info.Mode().Perm()&0111 != 0 {
+			icon = "🔧"
+		}
+		size = formatSize(info.Size(), siUnit)
+		mod = info.ModTime().Format("2006-01-02 15:04:05")
+	}
+	if ext := extIcon(item, types); ext != "" {
+		icon = ext
+	}
+	return table.Row{icon, filepath.Base(item), item, size, mod}
+}
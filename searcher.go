@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// searcher is where results come from: plocate, mlocate or GNU locate, the
+// filesystem itself when none is installed, or a fixture with -fake-backend
+type searcher interface {
+	// Search streams the paths matching every pattern. The channel closes when
+	// they've all been sent or ctx is cancelled; a failed search ends with a
+	// result carrying the error.
+	Search(ctx context.Context, patterns []string, opts searchOpts) (<-chan result, error)
+}
+
+type result struct {
+	path   string
+	source string // the database it's from, when several are searched
+	err    error
+}
+
+// searchOpts is how patterns are matched
+type searchOpts struct {
+	regex      bool // POSIX extended
+	basicRegex bool // POSIX basic
+	ignoreCase bool
+	basename   bool // match the last path component only
+	// How backends that walk the filesystem walk it; locate filters by root and ignore instead
+	root     string   // where to start, "" for /
+	maxDepth int      // levels below root to go, 0 for no limit
+	ignore   []string // file name globs not to list or go into
+}
+
+// backend and statPath are where results and their stats come from, a fixture
+// with -fake-backend. liveBackend walks the filesystem instead of reading an
+// index, nil when fd isn't installed.
+var (
+	backend     searcher = plocate
+	liveBackend searcher
+	statPath    = os.Stat
+)
+
+// locateSearcher runs a locate command, one process per search
+type locateSearcher struct {
+	command   string // plocate, mlocate or locate
+	database  string // where it reads from by default, for -about
+	findutils bool   // GNU locate, which spells its regex flags differently
+	host      string // where it runs over ssh with -remote, "" for this machine
+	pick      bool   // read database with -d, one of the config's databases
+}
+
+var (
+	plocate   = locateSearcher{command: "plocate", database: "/var/lib/plocate/plocate.db"}
+	mlocate   = locateSearcher{command: "mlocate", database: "/var/lib/mlocate/mlocate.db"}
+	gnuLocate = locateSearcher{command: "locate", database: "/var/cache/locate/locatedb", findutils: true}
+)
+
+// detectSearcher picks the first locate installed, preferring plocate for
+// speed. Plain locate is mlocate on most distros that still ship it, and GNU
+// findutils' own on the rest; only --version tells them apart.
+func detectSearcher() (locateSearcher, bool) {
+	for _, s := range []locateSearcher{plocate, mlocate} {
+		if _, err := exec.LookPath(s.command); err == nil {
+			return s, true
+		}
+	}
+	if _, err := exec.LookPath("locate"); err != nil {
+		return plocate, false
+	}
+	out, _ := exec.Command("locate", "--version").Output()
+	if bytes.Contains(out, []byte("findutils")) {
+		return gnuLocate, true
+	}
+	return locateSearcher{command: "locate", database: mlocate.database}, true
+}
+
+func (l locateSearcher) args(patterns []string, opts searchOpts) []string {
+	var args []string
+	if l.command != "plocate" {
+		args = append(args, "--all") // the others match any pattern rather than every one
+	}
+	switch {
+	case opts.regex && l.findutils:
+		args = append(args, "--regextype", "posix-extended", "--regex")
+	case opts.basicRegex && l.findutils:
+		args = append(args, "--regextype", "posix-basic", "--regex")
+	case opts.regex:
+		args = append(args, "--regex")
+	case opts.basicRegex && l.command == "plocate":
+		args = append(args, "--regexp")
+	}
+	if opts.ignoreCase {
+		args = append(args, "-i")
+	}
+	if opts.basename {
+		args = append(args, "-b")
+	}
+	if l.pick {
+		args = append(args, "-d", l.database)
+	}
+	if opts.basicRegex && !l.findutils && l.command != "plocate" {
+		// mlocate's --regexp takes the regex as its argument and allows no
+		// patterns after it, though it can be given once for each
+		for _, p := range patterns {
+			args = append(args, "--regexp", p)
+		}
+		return args
+	}
+	return append(append(args, "--"), patterns...)
+}
+
+// Search reads locate's null-separated output as it streams in. Not finding
+// anything isn't an error, only what locate prints to stderr is.
+func (l locateSearcher) Search(ctx context.Context, patterns []string, opts searchOpts) (<-chan result, error) {
+	return streamNull(ctx, l.cmd(ctx, append([]string{"-0"}, l.args(patterns, opts)...)...))
+}
+
+// cmd runs the locate command with args, on the remote host if there is one
+func (l locateSearcher) cmd(ctx context.Context, args ...string) *exec.Cmd {
+	if l.host != "" {
+		return exec.CommandContext(ctx, "ssh", sshArgs(l.host, false, append([]string{l.command}, args...)...)...)
+	}
+	return exec.CommandContext(ctx, l.command, args...)
+}
+
+// streamNull starts cmd and sends each null-separated path it prints, then
+// whatever it wrote to stderr as an error if it failed
+func streamNull(ctx context.Context, cmd *exec.Cmd) (<-chan result, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	results := make(chan result)
+	go func() {
+		defer close(results)
+		sc := bufio.NewScanner(stdout)
+		sc.Split(scanNull)
+		for sc.Scan() {
+			select {
+			case results <- result{path: sc.Text()}:
+			case <-ctx.Done():
+				cmd.Wait() // killed by the context, this only reaps it
+				return
+			}
+		}
+		if err := cmd.Wait(); err != nil && stderr.Len() > 0 {
+			select {
+			case results <- result{err: fmt.Errorf("%s", stderr.String())}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return results, nil
+}
+
+// compileRegex compiles a pattern to match paths with in Go, taking the
+// longest match the way POSIX does. Case is ignored with (?i): lowercasing
+// the pattern would turn escapes like \S and \W into their opposites.
+func compileRegex(pattern string, ignoreCase bool) (*regexp.Regexp, error) {
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	re.Longest()
+	return re, nil
+}
+
+// pathMatcher matches paths the way locate would with the same options, for
+// the backends that search on their own: every pattern has to match, as a
+// substring or a regex
+func pathMatcher(patterns []string, opts searchOpts) (func(path string) bool, error) {
+	ignoreCase, regex := opts.ignoreCase, opts.regex || opts.basicRegex
+	var res []*regexp.Regexp
+	if regex {
+		for _, p := range patterns {
+			re, err := compileRegex(p, ignoreCase) // close enough for basic regexes without \( \) groups
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex: %w", err)
+			}
+			res = append(res, re)
+		}
+	}
+	return func(path string) bool {
+		if opts.basename {
+			path = filepath.Base(path)
+		}
+		if regex {
+			return !slices.ContainsFunc(res, func(re *regexp.Regexp) bool { return !re.MatchString(path) })
+		}
+		if ignoreCase {
+			path = strings.ToLower(path)
+		}
+		return !slices.ContainsFunc(patterns, func(p string) bool {
+			if ignoreCase {
+				p = strings.ToLower(p)
+			}
+			return !strings.Contains(path, p)
+		})
+	}, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestPathMatcher(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		opts     searchOpts
+		path     string
+		match    bool
+	}{
+		{[]string{"report"}, searchOpts{}, "/x/report.pdf", true},
+		{[]string{"Report"}, searchOpts{}, "/x/report.pdf", false},
+		{[]string{"Report"}, searchOpts{ignoreCase: true}, "/x/REPORT.pdf", true},
+		{[]string{"x", "pdf"}, searchOpts{}, "/x/report.pdf", true},
+		{[]string{"x", "doc"}, searchOpts{}, "/x/report.pdf", false}, // every pattern
+		{[]string{"x"}, searchOpts{basename: true}, "/x/report.pdf", false},
+
+		{[]string{`\.pdf$`}, searchOpts{regex: true}, "/x/report.pdf", true},
+		{[]string{`^/x/\S+$`}, searchOpts{regex: true}, "/x/my report.pdf", false},
+		{[]string{`^/x/\S+$`}, searchOpts{regex: true, ignoreCase: true}, "/x/Report.PDF", true},
+		{[]string{`^/x/\S+$`}, searchOpts{regex: true, ignoreCase: true}, "/x/my report.pdf", false},
+		{[]string{`^/x/\D+$`}, searchOpts{regex: true, ignoreCase: true}, "/x/Notes", true},
+		{[]string{`^/x/\D+$`}, searchOpts{regex: true, ignoreCase: true}, "/x/notes2", false},
+		{[]string{`REPORT\.pdf`}, searchOpts{regex: true, ignoreCase: true}, "/x/report.PDF", true},
+		{[]string{`^report`}, searchOpts{basicRegex: true, basename: true}, "/x/report.pdf", true},
+	}
+	for _, tt := range tests {
+		match, err := pathMatcher(tt.patterns, tt.opts)
+		if err != nil {
+			t.Errorf("pathMatcher(%q, %+v): %v", tt.patterns, tt.opts, err)
+			continue
+		}
+		if got := match(tt.path); got != tt.match {
+			t.Errorf("%q %+v matching %q = %v, want %v", tt.patterns, tt.opts, tt.path, got, tt.match)
+		}
+	}
+}
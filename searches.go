@@ -0,0 +1,179 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// savedSearch is a query kept under a name along with the settings it was typed under
+type savedSearch struct {
+	Name       string `json:"name"`
+	Query      string `json:"query"`
+	MatchMode  string `json:"match_mode,omitempty"` // one of matchModeNames, empty for substring
+	IgnoreCase bool   `json:"ignore_case,omitempty"`
+	Basename   bool   `json:"basename,omitempty"`
+	Profile    string `json:"profile,omitempty"`
+}
+
+var (
+	searchOpen   = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "search"))
+	searchUp     = key.NewBinding(key.WithKeys("up", "ctrl+p"), key.WithHelp("↑/↓", "select"))
+	searchDown   = key.NewBinding(key.WithKeys("down", "ctrl+n"))
+	searchDelete = key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "delete"))
+)
+
+// currentSearch captures the query as it's being searched right now
+func (m model) currentSearch() savedSearch {
+	s := savedSearch{Query: m.searchQuery, IgnoreCase: m.ignoreCase, Basename: m.basename}
+	if m.matchMode != matchSubstring {
+		s.MatchMode = matchModeNames[m.matchMode]
+	}
+	if p := m.activeProfile(); p != nil {
+		s.Profile = p.Name
+	}
+	return s
+}
+
+// saveSearch adds s to the config, replacing an earlier search with the same name
+func (m model) saveSearch(s savedSearch) tea.Cmd {
+	cfg := m.cfg
+	cfg.Searches = slices.DeleteFunc(slices.Clone(cfg.Searches), func(o savedSearch) bool { return o.Name == s.Name })
+	cfg.Searches = append(cfg.Searches, s)
+	return tea.Batch(func() tea.Msg { return configChangedMsg{cfg} }, notify(toastInfo, "Saved search "+s.Name))
+}
+
+// runSavedSearch puts a saved search back in the input under its settings
+func (m *model) runSavedSearch(s savedSearch) tea.Cmd {
+	m.matchMode = matchSubstring
+	for mode, name := range matchModeNames {
+		if name == s.MatchMode {
+			m.matchMode = mode
+		}
+	}
+	m.ignoreCase, m.basename = s.IgnoreCase, s.Basename
+	var cmd tea.Cmd
+	if i := slices.IndexFunc(m.profiles(), func(p profile) bool { return p.Name == s.Profile }); i != m.profile {
+		cmd = m.useProfile(i)
+	}
+	m.setTableFocus(false)
+	m.textInput.SetValue(s.Query)
+	m.textInput.CursorEnd()
+	m.lastQuery = "" // search again even if the text is the same
+	return cmd
+}
+
+// saveSearchMsg is the named search to add to the config
+type saveSearchMsg struct {
+	search savedSearch
+}
+
+// newSaveSearchDialog asks what to call the query being saved, the query itself if left empty
+func newSaveSearchDialog(s savedSearch) promptDialog {
+	return newPromptDialog("Save search", s.Query, "Name: ", "", func(name string) tea.Msg {
+		s.Name = cmp.Or(strings.TrimSpace(name), s.Query)
+		return saveSearchMsg{s}
+	})
+}
+
+// runSavedSearchMsg picks a saved search from the list
+type runSavedSearchMsg struct {
+	search savedSearch
+}
+
+// searchesDialog lists the saved searches, filtered and ranked by the same
+// fuzzy matching as results as the user types
+type searchesDialog struct {
+	searches []savedSearch
+	shown    []savedSearch
+	cursor   int
+	filter   textinput.Model
+}
+
+func newSearchesDialog(searches []savedSearch) searchesDialog {
+	ti := textinput.New()
+	ti.Placeholder = "Filter saved searches..."
+	ti.Width = 40
+	ti.Focus()
+	return searchesDialog{searches: searches, shown: searches, filter: ti}
+}
+
+func (d searchesDialog) Update(msg tea.Msg) (dialog, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(k, searchUp):
+			d.cursor = max(d.cursor-1, 0)
+			return d, nil
+		case key.Matches(k, searchDown):
+			d.cursor = max(min(d.cursor+1, len(d.shown)-1), 0)
+			return d, nil
+		case key.Matches(k, searchOpen):
+			if d.cursor >= len(d.shown) {
+				return d, nil
+			}
+			s := d.shown[d.cursor]
+			return d, tea.Batch(closeTopDialog, func() tea.Msg { return runSavedSearchMsg{s} })
+		case key.Matches(k, searchDelete):
+			if d.cursor >= len(d.shown) {
+				return d, nil
+			}
+			name := d.shown[d.cursor].Name
+			d.searches = slices.DeleteFunc(slices.Clone(d.searches), func(s savedSearch) bool { return s.Name == name })
+			d.refilter()
+			searches := d.searches
+			return d, func() tea.Msg { return deleteSearchMsg{searches} }
+		}
+	}
+	var cmd tea.Cmd
+	d.filter, cmd = d.filter.Update(msg)
+	d.refilter()
+	return d, cmd
+}
+
+// deleteSearchMsg carries the saved searches left after deleting one
+type deleteSearchMsg struct {
+	searches []savedSearch
+}
+
+// refilter ranks the searches whose name or query fuzzily match the filter, best first
+func (d *searchesDialog) refilter() {
+	term := strings.ToLower(strings.TrimSpace(d.filter.Value()))
+	type ranked struct {
+		search savedSearch
+		score  int
+	}
+	var matches []ranked
+	for _, s := range d.searches {
+		if score, ok := fuzzyScore(strings.ToLower(s.Name+" "+s.Query), term); ok {
+			matches = append(matches, ranked{s, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b ranked) int { return cmp.Compare(b.score, a.score) }) // saved order while the filter is empty
+	d.shown = nil
+	for _, r := range matches {
+		d.shown = append(d.shown, r.search)
+	}
+	d.cursor = max(min(d.cursor, len(d.shown)-1), 0)
+}
+
+func (d searchesDialog) View() string {
+	var b strings.Builder
+	b.WriteString(dialogTitleStyle.Render("Saved searches") + "\n\n" + d.filter.View() + "\n\n")
+	if len(d.searches) == 0 {
+		b.WriteString(settingsDimStyle.Render("Nothing saved yet, press "+keys.SaveSearch.Help().Key+" on a search") + "\n")
+	}
+	for i, s := range d.shown {
+		line := fmt.Sprintf("  %-20s %s", s.Name, settingsDimStyle.Render(s.Query))
+		if i == d.cursor {
+			line = settingsCursorStyle.Render("› " + s.Name + strings.Repeat(" ", max(20-len([]rune(s.Name)), 0)) + " " + s.Query)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String() + "\n" + help.New().ShortHelpView([]key.Binding{searchUp, searchOpen, searchDelete, closeDialog})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// filesPlaceholder in a command template is replaced by the selected paths, one argument each
+const filesPlaceholder = "{files}"
+
+// expandTemplate builds the argv for a configured command; without a
+// placeholder the paths go at the end
+func expandTemplate(argv, paths []string) ([]string, error) {
+	if len(argv) == 0 {
+		return nil, errors.New("no command configured")
+	}
+	i := slices.Index(argv, filesPlaceholder)
+	if i < 0 {
+		return append(slices.Clone(argv), paths...), nil
+	}
+	return slices.Concat(argv[:i], paths, argv[i+1:]), nil
+}
+
+// transferMsg is one line of output from a running send, or its end once done is set
+type transferMsg struct {
+	line   string
+	done   bool
+	err    error
+	next   <-chan tea.Msg // the next line, or the end with the audit log written
+	paths  []string       // the files sent, set once done
+	target string         // the command line that sent them
+}
+
+// runTransfer starts argv and streams its output back line by line, splitting
+// on the carriage returns rsync redraws its progress with. It's listed as a
+// job while it runs, with rsync's percentage as its progress, and killed if
+// the job is cancelled.
+func runTransfer(jm *jobManager, argv, paths []string) tea.Cmd {
+	target := strings.Join(argv, " ")
+	return func() tea.Msg {
+		ctx, done := jm.track(jobCopy, fmt.Sprintf("send %d files", len(paths)))
+		ch := make(chan tea.Msg)
+		logEntry := auditEntry{Action: "send", Paths: paths, Target: target}
+		c := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		pr, pw := io.Pipe()
+		c.Stdout, c.Stderr = pw, pw
+		c.WaitDelay = time.Second // for what it started, e.g. rsync's ssh, to let go of the output once it's killed
+		if err := c.Start(); err != nil {
+			done()
+			return logged(transferMsg{done: true, err: err, paths: paths, target: target}, logEntry, err)
+		}
+		go func() {
+			err := c.Wait()
+			pw.CloseWithError(err)
+		}()
+		go func() {
+			var last string
+			sc := bufio.NewScanner(pr)
+			sc.Split(scanLinesCR)
+			for sc.Scan() {
+				if line := string(bytes.TrimSpace(sc.Bytes())); line != "" {
+					last = line
+					if m := rsyncProgress.FindStringSubmatch(line); m != nil {
+						pct, _ := strconv.Atoi(m[1])
+						reportProgress(ctx, int64(pct), 100)
+					}
+					ch <- transferMsg{line: line, next: ch}
+				}
+			}
+			err := sc.Err()
+			if ctx.Err() != nil {
+				err = ctx.Err() // killed, rather than failed
+			} else if err != nil && last != "" { // the exit status alone says little, the last line usually explains it
+				err = fmt.Errorf("%w: %s", err, last)
+			}
+			done()
+			ch <- logged(transferMsg{done: true, err: err, paths: paths, target: target}, logEntry, err)
+		}()
+		return <-ch
+	}
+}
+
+// waitTransfer reads the next line of a running transfer
+func waitTransfer(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg { return <-ch }
+}
+
+// scanLinesCR is bufio.ScanLines that also ends a line at \r
+func scanLinesCR(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// rsyncProgress matches the percentage and rate in rsync's --info=progress2 or --progress lines
+var rsyncProgress = regexp.MustCompile(`(\d+)%\s+(\S+/s)`)
+
+// transferStatus turns a line of transfer output into the status bar text
+func transferStatus(line string) string {
+	if m := rsyncProgress.FindStringSubmatch(line); m != nil {
+		return fmt.Sprintf("Sending… %s%% at %s", m[1], m[2])
+	}
+	return "Sending… " + line
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	settingTheme = iota
+	settingSize
+	settingModified
+	settingDatabase
+	settingDebounce
+	settingUnits
+	settingPaths
+	settingQuick
+	settingOrder
+	settingZebra
+	settingDensity
+	settingGroups
+	settingWatch
+	settingKeys // one row per entry in actionNames from here on
+)
+
+const (
+	maxDebounceMs   = 1000
+	maxWatchSeconds = 60
+)
+
+var (
+	settingsUp     = key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/↓", "select"))
+	settingsDown   = key.NewBinding(key.WithKeys("down", "j"))
+	settingsLeft   = key.NewBinding(key.WithKeys("left", "h"))
+	settingsRight  = key.NewBinding(key.WithKeys("right", "l", "enter", " "), key.WithHelp("←/→", "change"))
+	settingsRebind = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "rebind"))
+
+	settingsCursorStyle = lipgloss.NewStyle().Bold(true)
+	settingsDimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+)
+
+// configChangedMsg carries every edit made on the settings screen back to the model
+type configChangedMsg struct {
+	cfg config
+}
+
+type settingsDialog struct {
+	cfg       config
+	cursor    int
+	capturing bool // the next key pressed becomes the binding for the selected action
+	help      help.Model
+}
+
+func newSettingsDialog(cfg config) settingsDialog {
+	return settingsDialog{cfg: cfg, help: help.New()}
+}
+
+func (d settingsDialog) Update(msg tea.Msg) (dialog, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return d, nil
+	}
+	if d.capturing {
+		d.capturing = false
+		d.cfg.Keys = maps.Clone(d.cfg.Keys)
+		if d.cfg.Keys == nil {
+			d.cfg.Keys = map[string][]string{}
+		}
+		d.cfg.Keys[actionNames[d.cursor-settingKeys]] = []string{k.String()}
+		return d, d.changed()
+	}
+
+	last := settingKeys + len(actionNames) - 1
+	switch {
+	case key.Matches(k, settingsUp):
+		d.cursor = max(d.cursor-1, 0)
+	case key.Matches(k, settingsDown):
+		d.cursor = min(d.cursor+1, last)
+	case d.cursor >= settingKeys:
+		if key.Matches(k, settingsRebind) {
+			d.capturing = true
+		}
+	case key.Matches(k, settingsLeft):
+		return d.step(-1)
+	case key.Matches(k, settingsRight):
+		return d.step(1)
+	}
+	return d, nil
+}
+
+// step moves the selected setting one notch in dir
+func (d settingsDialog) step(dir int) (dialog, tea.Cmd) {
+	switch d.cursor {
+	case settingTheme:
+		i := slices.Index(themeNames, d.cfg.Theme)
+		d.cfg.Theme = themeNames[(max(i, 0)+dir+len(themeNames))%len(themeNames)]
+	case settingSize:
+		d.cfg.ShowSize = !d.cfg.ShowSize
+	case settingModified:
+		d.cfg.ShowModified = !d.cfg.ShowModified
+	case settingDatabase:
+		d.cfg.ShowDatabase = !d.cfg.ShowDatabase
+	case settingDebounce:
+		d.cfg.DebounceMs = min(max(d.cfg.DebounceMs+dir*50, 0), maxDebounceMs)
+	case settingUnits:
+		d.cfg.SIUnits = !d.cfg.SIUnits
+	case settingPaths:
+		d.cfg.HomePaths = !d.cfg.HomePaths
+	case settingQuick:
+		d.cfg.QuickOpen = !d.cfg.QuickOpen
+	case settingOrder:
+		d.cfg.Order = orderNames[(parseOrder(d.cfg.Order)+resultOrder(dir)+orderCount)%orderCount]
+	case settingZebra:
+		d.cfg.ZebraRows = !d.cfg.ZebraRows
+	case settingDensity:
+		i := slices.Index(densityNames, d.cfg.Density)
+		d.cfg.Density = densityNames[(max(i, 0)+dir+len(densityNames))%len(densityNames)]
+	case settingGroups:
+		d.cfg.Separators = !d.cfg.Separators
+	case settingWatch:
+		d.cfg.WatchSecs = min(max(cmp.Or(d.cfg.WatchSecs, defaultWatchSeconds)+dir, 1), maxWatchSeconds)
+	}
+	return d, d.changed()
+}
+
+func (d settingsDialog) changed() tea.Cmd {
+	cfg := d.cfg
+	return func() tea.Msg { return configChangedMsg{cfg} }
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func (d settingsDialog) View() string {
+	units := "binary (KiB)"
+	if d.cfg.SIUnits {
+		units = "SI (KB)"
+	}
+	paths := "absolute"
+	if d.cfg.HomePaths {
+		paths = "relative to ~"
+	}
+	quick := "copy path"
+	if d.cfg.QuickOpen {
+		quick = "open file"
+	}
+	density := cmp.Or(d.cfg.Density, "normal")
+	values := []string{
+		d.cfg.Theme,
+		onOff(d.cfg.ShowSize),
+		onOff(d.cfg.ShowModified),
+		onOff(d.cfg.ShowDatabase),
+		fmt.Sprintf("%d ms", d.cfg.DebounceMs),
+		units,
+		paths,
+		quick,
+		orderDescriptions[parseOrder(d.cfg.Order)],
+		onOff(d.cfg.ZebraRows),
+		density,
+		onOff(d.cfg.Separators),
+		fmt.Sprintf("%d s", cmp.Or(d.cfg.WatchSecs, defaultWatchSeconds)),
+	}
+	labels := []string{"Theme", "Size column", "Modified column", "Database column", "Search debounce", "Size units", "Paths", "alt+1…9", "Result order", "Striped rows", "Row density", "Dir separators", "Watch interval"}
+	bound := keys.withOverrides(d.cfg.Keys)
+	for _, name := range actionNames {
+		labels = append(labels, "Key: "+name)
+		values = append(values, strings.Join(bound.action(name).Keys(), "/"))
+	}
+
+	var b strings.Builder
+	b.WriteString(dialogTitleStyle.Render("Settings") + "\n\n")
+	for i, label := range labels {
+		line := fmt.Sprintf("  %-18s %s", label, values[i])
+		if i == d.cursor {
+			if d.capturing {
+				line = fmt.Sprintf("› %-18s press a key…", label)
+			} else {
+				line = "›" + line[1:]
+			}
+			line = settingsCursorStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	path, err := configPath()
+	if err == nil {
+		b.WriteString("\n" + settingsDimStyle.Render("saved to "+path))
+	}
+	hints := []key.Binding{settingsUp, settingsRight, closeDialog}
+	if d.cursor >= settingKeys {
+		hints = []key.Binding{settingsUp, settingsRebind, closeDialog}
+	}
+	return b.String() + "\n\n" + d.help.ShortHelpView(hints)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// shareMsg reports how a share went; err joins every file that failed
+type shareMsg struct {
+	done   string
+	err    error
+	paths  []string
+	target string // the share dir or command the files went to
+}
+
+// share hands paths over the way the config asks: copied into share_dir, given
+// to share_command, or else attached to a new mail with xdg-email
+func share(cfg config, paths []string) tea.Cmd {
+	return func() tea.Msg {
+		msg := shareFiles(cfg, paths)
+		return logged(msg, auditEntry{Action: "share", Paths: paths, Target: msg.target}, msg.err)
+	}
+}
+
+// shareFiles does the handing over for share, which logs how it went
+func shareFiles(cfg config, paths []string) shareMsg {
+	switch {
+	case cfg.ShareDir != "":
+		n, err := copyInto(cfg.ShareDir, paths)
+		return shareMsg{fmt.Sprintf("Copied %d of %d to %s", n, len(paths), cfg.ShareDir), err, paths, cfg.ShareDir}
+	case len(cfg.ShareCommand) > 0:
+		argv, _ := expandTemplate(cfg.ShareCommand, paths)
+		target := strings.Join(cfg.ShareCommand, " ")
+		if out, err := exec.Command(argv[0], argv[1:]...).CombinedOutput(); err != nil {
+			return shareMsg{err: fmt.Errorf("%s: %w: %s", argv[0], err, strings.TrimSpace(string(out))), paths: paths, target: target}
+		}
+		return shareMsg{done: fmt.Sprintf("Shared %d files", len(paths)), paths: paths, target: target}
+	}
+	argv := []string{"xdg-email"}
+	for _, p := range paths {
+		argv = append(argv, "--attach", p)
+	}
+	if err := exec.Command(argv[0], argv[1:]...).Start(); err != nil { // the mail client outlives us
+		return shareMsg{err: err, paths: paths, target: argv[0]}
+	}
+	return shareMsg{done: fmt.Sprintf("Attached %d files to a new mail", len(paths)), paths: paths, target: argv[0]}
+}
+
+// copyInto copies regular files into dir without replacing anything already there
+func copyInto(dir string, paths []string) (int, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, err
+	}
+	var errs []error
+	n := 0
+	for _, p := range paths {
+		if err := copyFile(p, filepath.Join(dir, filepath.Base(p))); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s: only regular files can be shared", src)
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
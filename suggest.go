@@ -0,0 +1,139 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxSuggestions is how many past queries and saved searches the dropdown lists
+const maxSuggestions = 6
+
+// suggestion is a past query, or a saved search when search is set
+type suggestion struct {
+	query  string
+	search *savedSearch
+}
+
+// suggestState is the dropdown under the input for the query as typed
+type suggestState struct {
+	query  string // what it was worked out for; typing anything else starts over
+	cursor int    // -1 until an arrow key picks one
+	hidden bool   // dismissed, or the query is one just picked from it
+}
+
+// suggestions are the saved searches and then the past queries containing
+// what's typed, ignoring case, those starting with it first
+func (m model) suggestions() []suggestion {
+	typed := strings.ToLower(strings.TrimSpace(m.searchQuery))
+	if typed == "" || m.suggest.hidden || m.tableFocused || m.prefix.Focused() {
+		return nil
+	}
+	var found []suggestion
+	add := func(query string, s *savedSearch) {
+		q := strings.ToLower(query)
+		if q == typed || !strings.Contains(q, typed) && (s == nil || !strings.Contains(strings.ToLower(s.Name), typed)) {
+			return
+		}
+		found = append(found, suggestion{query, s})
+	}
+	for i := range m.cfg.Searches {
+		add(m.cfg.Searches[i].Query, &m.cfg.Searches[i])
+	}
+	for _, q := range m.queryHistory {
+		if !slices.ContainsFunc(found, func(s suggestion) bool { return s.query == q }) {
+			add(q, nil)
+		}
+	}
+	rank := func(s suggestion) int {
+		if strings.HasPrefix(strings.ToLower(s.query), typed) {
+			return 0
+		}
+		return 1
+	}
+	slices.SortStableFunc(found, func(a, b suggestion) int { return cmp.Compare(rank(a), rank(b)) })
+	return found[:min(len(found), maxSuggestions)]
+}
+
+// suggestKey moves through the dropdown with the arrow keys while it's showing
+// and takes the picked query with enter; esc closes it. Up from the first
+// suggestion leaves it without picking any.
+func (m *model) suggestKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	items := m.suggestions()
+	if len(items) == 0 {
+		return false, nil
+	}
+	switch msg.Type {
+	case tea.KeyDown:
+		m.suggest.cursor = min(m.suggest.cursor+1, len(items)-1)
+		return true, nil
+	case tea.KeyUp:
+		m.suggest.cursor = max(m.suggest.cursor-1, -1)
+		return true, nil
+	case tea.KeyEsc:
+		if m.suggest.cursor < 0 {
+			return false, nil // esc still goes to the results when nothing is picked
+		}
+		m.suggest.hidden = true
+		return true, nil
+	case tea.KeyEnter:
+		if m.suggest.cursor < 0 {
+			return false, nil
+		}
+		picked := items[m.suggest.cursor]
+		if picked.search != nil {
+			cmd = m.runSavedSearch(*picked.search)
+		} else {
+			m.textInput.SetValue(picked.query)
+			m.textInput.CursorEnd()
+		}
+		m.suggest = suggestState{query: m.textInput.Value(), cursor: -1, hidden: true}
+		return true, cmd
+	}
+	return false, nil
+}
+
+// rememberQuery adds the query being acted on to the history the dropdown
+// suggests from. Only queries whose results get used are kept, not every
+// prefix typed on the way to them.
+func (m *model) rememberQuery() tea.Cmd {
+	query := strings.TrimSpace(m.searchQuery)
+	if query == "" || len(m.queryHistory) > 0 && m.queryHistory[0] == query {
+		return nil
+	}
+	m.queryHistory = slices.Insert(slices.DeleteFunc(slices.Clone(m.queryHistory), func(q string) bool { return q == query }), 0, query)
+	if err := saveHistory("query", query); err != nil {
+		return notify(toastWarn, "Couldn't save the query history: "+err.Error())
+	}
+	return nil
+}
+
+var (
+	suggestStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240")).Padding(0, 1)
+	savedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+)
+
+// suggestView draws the dropdown, "" when there's nothing to suggest
+func (m model) suggestView(width int) string {
+	items := m.suggestions()
+	if len(items) == 0 {
+		return ""
+	}
+	var lines []string
+	for i, s := range items {
+		line := truncateRight(s.query, width)
+		if s.search != nil {
+			line += savedStyle.Render("  saved as " + s.search.Name)
+		}
+		if i == m.suggest.cursor {
+			line = settingsCursorStyle.Render("› " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	return suggestStyle.Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var themeNames = []string{"blue", "green", "purple", "red"}
+
+var themes = map[string]lipgloss.Color{
+	"blue":   lipgloss.Color("#3e6589"),
+	"green":  lipgloss.Color("#3e8953"),
+	"purple": lipgloss.Color("#6a4e9a"),
+	"red":    lipgloss.Color("#9a3e4e"),
+}
+
+// themesANSI stand in on 16-colour terminals, where the hex accents would be
+// rounded to whatever basic colour happens to be nearest
+var themesANSI = map[string]lipgloss.Color{
+	"blue":   lipgloss.Color("4"),
+	"green":  lipgloss.Color("2"),
+	"purple": lipgloss.Color("5"),
+	"red":    lipgloss.Color("1"),
+}
+
+// basicColors is true on 16-colour and monochrome terminals (serial consoles,
+// some SSH setups, NO_COLOR), where a coloured background behind text is often
+// unreadable, so highlights use reverse video instead
+func basicColors() bool {
+	return lipgloss.ColorProfile() >= termenv.ANSI
+}
+
+// applyTheme recolours everything drawn with the accent colour and returns the matching table styles
+func applyTheme(name string) table.Styles {
+	if _, ok := themes[name]; !ok {
+		name = "blue"
+	}
+	accent := themes[name]
+	if basicColors() {
+		accent = themesANSI[name]
+	}
+	baseStyle = baseStyle.BorderForeground(accent)
+	dialogStyle = dialogStyle.BorderForeground(accent)
+	dialogTitleStyle = dialogTitleStyle.Foreground(accent)
+	toastColors[toastInfo] = accent
+
+	s := table.DefaultStyles()
+	s.Header = s.Header.BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).BorderBottom(true).Bold(false)
+	s.Selected = s.Selected.Foreground(lipgloss.Color("229")).
+		Background(accent).Bold(false)
+	if basicColors() {
+		s.Selected = lipgloss.NewStyle().Reverse(true).Bold(true)
+		buttonStyle = buttonStyle.UnsetForeground().UnsetBackground().Reverse(true)
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	toastDuration = 4 * time.Second
+	maxToasts     = 4
+)
+
+type toastLevel int
+
+const (
+	toastInfo toastLevel = iota
+	toastWarn
+	toastError
+)
+
+var toastColors = map[toastLevel]lipgloss.Color{
+	toastInfo:  lipgloss.Color("#3e6589"),
+	toastWarn:  lipgloss.Color("#b58900"),
+	toastError: lipgloss.Color("#dc322f"),
+}
+
+type toast struct {
+	id    int
+	level toastLevel
+	text  string
+}
+
+type toastMsg struct {
+	level toastLevel
+	text  string
+}
+
+type toastExpiredMsg struct {
+	id int
+}
+
+type toasts struct {
+	items  []toast
+	nextID int
+}
+
+// notify lets any part of the program pop a toast without touching the model
+func notify(level toastLevel, text string) tea.Cmd {
+	return func() tea.Msg {
+		return toastMsg{level, text}
+	}
+}
+
+func (t toasts) push(msg toastMsg) (toasts, tea.Cmd) {
+	t.nextID++
+	id := t.nextID
+	t.items = append(t.items, toast{id, msg.level, msg.text})
+	if len(t.items) > maxToasts { // drop the oldest rather than covering the whole table
+		t.items = t.items[len(t.items)-maxToasts:]
+	}
+	return t, tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{id}
+	})
+}
+
+func (t toasts) expire(id int) toasts {
+	items := make([]toast, 0, len(t.items))
+	for _, it := range t.items {
+		if it.id != id {
+			items = append(items, it)
+		}
+	}
+	t.items = items
+	return t
+}
+
+// render draws the stack newest-first, each toast in its severity colour
+func (t toasts) render(maxWidth int) string {
+	var boxes []string
+	for i := len(t.items) - 1; i >= 0; i-- {
+		it := t.items[i]
+		boxes = append(boxes, lipgloss.NewStyle().
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(toastColors[it.level]).
+			Foreground(toastColors[it.level]).
+			Padding(0, 1).
+			MaxWidth(maxWidth).
+			Render(ansi.Truncate(it.text, max(maxWidth-4, 1), "…")))
+	}
+	return lipgloss.JoinVertical(lipgloss.Right, boxes...)
+}
+
+// placeOverlay draws fg over bg with its top-left corner at column x, row y
+func placeOverlay(x, y int, fg, bg string) string {
+	bgLines := strings.Split(bg, "\n")
+	for i, line := range strings.Split(fg, "\n") {
+		row := y + i
+		if row < 0 || row >= len(bgLines) {
+			continue
+		}
+		b := bgLines[row]
+		left := ansi.Truncate(b, x, "")
+		if pad := x - ansi.StringWidth(left); pad > 0 {
+			left += strings.Repeat(" ", pad)
+		}
+		right := ansi.TruncateLeft(b, x+ansi.StringWidth(line), "")
+		bgLines[row] = left + line + "\x1b[0m" + right
+	}
+	return strings.Join(bgLines, "\n")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeAheadTimeout is how long a typed prefix lasts before the next letter starts a new one
+const typeAheadTimeout = time.Second
+
+// typeAhead is the file name prefix being typed while the table has focus
+type typeAhead struct {
+	prefix string
+	missed bool // no file name starts with prefix
+	seq    int
+}
+
+type typeAheadExpiredMsg struct{ seq int }
+
+// typeAheadKey adds the typed letters to the prefix and moves the cursor to the
+// next row whose file name starts with it. Pressing the same letter again steps
+// through the rows starting with that letter, like a file manager.
+func (m *model) typeAheadKey(msg tea.KeyMsg) tea.Cmd {
+	from := m.table.Cursor()
+	if m.typeAhead.prefix == "" {
+		from++ // a fresh prefix looks past the current row
+	}
+	m.typeAhead.prefix += string(msg.Runes)
+	m.typeAhead.seq++
+
+	i := m.findPrefix(m.typeAhead.prefix, from)
+	if first, _ := utf8.DecodeRuneInString(m.typeAhead.prefix); i < 0 && strings.Trim(m.typeAhead.prefix, string(first)) == "" {
+		i = m.findPrefix(string(first), m.table.Cursor()+1)
+	}
+	m.typeAhead.missed = i < 0
+	if i >= 0 {
+		m.table.SetCursor(i)
+	}
+
+	seq := m.typeAhead.seq
+	return tea.Tick(typeAheadTimeout, func(time.Time) tea.Msg { return typeAheadExpiredMsg{seq} })
+}
+
+// findPrefix returns the first row from index from on, wrapping around, whose
+// file name starts with prefix ignoring case, or -1
+func (m model) findPrefix(prefix string, from int) int {
+	prefix = strings.ToLower(prefix)
+	for n := range len(m.rows) {
+		i := (from + n) % len(m.rows)
+		if strings.HasPrefix(strings.ToLower(filepath.Base(m.rows[i][2])), prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// typeAheadStatus shows the prefix in the status bar while it lasts
+func (m model) typeAheadStatus() string {
+	switch {
+	case m.typeAhead.prefix == "":
+		return ""
+	case m.typeAhead.missed:
+		return "find: " + m.typeAhead.prefix + " (no match)"
+	}
+	return "find: " + m.typeAhead.prefix
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// fileType groups extensions under a name, e.g. "images", for type: clauses,
+// the icon a result gets and the per type summary while sorting by type
+type fileType struct {
+	Name       string   `json:"name"`
+	Extensions []string `json:"extensions"`     // without the dot, any case
+	Icon       string   `json:"icon,omitempty"` // empty for the plain file icon
+}
+
+func defaultFileTypes() []fileType {
+	return []fileType{
+		{Name: "images", Extensions: []string{"png", "jpg", "jpeg", "webp", "heic", "gif", "svg"}, Icon: "🎨"},
+		{Name: "video", Extensions: []string{"mp4", "mkv", "mov", "webm", "avi"}, Icon: "📹"},
+		{Name: "audio", Extensions: []string{"mp3", "flac", "ogg", "opus", "wav", "m4a"}, Icon: "🎵"},
+		{Name: "archives", Extensions: []string{"zip", "gz", "7z", "tar", "xz", "bz2", "zst", "rar"}, Icon: "📦"},
+		{Name: "documents", Extensions: []string{"pdf", "doc", "docx", "odt", "txt", "md", "rtf"}},
+	}
+}
+
+// fileTypes are the built-in types with the config's in place of those of the
+// same name and after the rest. The model keeps them and hands them to each
+// query, so searches running meanwhile aren't affected by a config change.
+type fileTypes []fileType
+
+// mergeFileTypes adds the config's types to the built-in ones, replacing any of
+// the same name. Types named like one of fileKinds would be shadowed by it in
+// type: clauses, so they're left out and named in the error.
+func mergeFileTypes(custom []fileType) (fileTypes, error) {
+	types := fileTypes(defaultFileTypes())
+	var reserved []string
+	for _, t := range custom {
+		if _, ok := fileKinds[t.Name]; ok {
+			reserved = append(reserved, t.Name)
+			continue
+		}
+		if i := slices.IndexFunc(types, func(d fileType) bool { return d.Name == t.Name }); i >= 0 {
+			types[i] = t
+		} else {
+			types = append(types, t)
+		}
+	}
+	if len(reserved) > 0 {
+		return types, fmt.Errorf("file_types: %s already means a kind of file in type: clauses, rename it", strings.Join(reserved, ", "))
+	}
+	return types, nil
+}
+
+// typeOf is the type path's extension belongs to, the first listed if several
+// claim it, or nil for none
+func (ts fileTypes) typeOf(path string) *fileType {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if ext == "" {
+		return nil
+	}
+	for i, t := range ts {
+		if slices.ContainsFunc(t.Extensions, func(e string) bool { return strings.EqualFold(e, ext) }) {
+			return &ts[i]
+		}
+	}
+	return nil
+}
+
+// fileKinds are the type: names told apart by the stat rather than the extension
+var fileKinds = map[string]func(os.FileInfo) bool{
+	"dir":  os.FileInfo.IsDir,
+	"file": func(info os.FileInfo) bool { return info.Mode().IsRegular() },
+}
+
+// typeFilter keeps paths of any of the named types or kinds, for type: clauses
+func typeFilter(names []string, types fileTypes) (filter, error) {
+	var kinds []func(os.FileInfo) bool
+	for _, name := range names {
+		if kind, ok := fileKinds[name]; ok {
+			kinds = append(kinds, kind)
+		} else if !slices.ContainsFunc(types, func(t fileType) bool { return t.Name == name }) {
+			known := slices.Sorted(maps.Keys(fileKinds))
+			for _, t := range types {
+				known = append(known, t.Name)
+			}
+			return filter{}, fmt.Errorf("type:%s: no such type, use one of %s or add it under file_types in the config", name, strings.Join(known, ", "))
+		}
+	}
+	return filter{needsStat: len(kinds) > 0, keep: func(path string, info os.FileInfo) bool {
+		if t := types.typeOf(path); t != nil && slices.Contains(names, t.Name) {
+			return true
+		}
+		return slices.ContainsFunc(kinds, func(kind func(os.FileInfo) bool) bool { return kind(info) })
+	}}, nil
+}
+
+// typeTotal counts the loaded results of one type
+type typeTotal struct {
+	name  string
+	count int
+	rank  int // where the type is listed, for grouping rows in the same order
+}
+
+// typeTotals counts the loaded results per type, most first, with those of
+// no type as "other" at the end
+func (m model) typeTotals() []typeTotal {
+	byType := map[string]*typeTotal{}
+	for _, row := range m.results {
+		name, rank := "other", len(m.types)
+		if t := m.types.typeOf(row[2]); t != nil {
+			name, rank = t.Name, slices.IndexFunc(m.types, func(f fileType) bool { return f.Name == t.Name })
+		}
+		if byType[name] == nil {
+			byType[name] = &typeTotal{name: name, rank: rank}
+		}
+		byType[name].count++
+	}
+	totals := make([]typeTotal, 0, len(byType))
+	for _, t := range byType {
+		totals = append(totals, *t)
+	}
+	last := func(t typeTotal) int {
+		if t.rank == len(m.types) {
+			return 1
+		}
+		return 0
+	}
+	slices.SortFunc(totals, func(a, b typeTotal) int {
+		return cmp.Or(cmp.Compare(last(a), last(b)), cmp.Compare(b.count, a.count), cmp.Compare(a.rank, b.rank))
+	})
+	return totals
+}
+
+// typeSummary is the status line suffix while sorting by type
+func (m model) typeSummary() string {
+	var parts []string
+	for _, t := range m.typeTotals() {
+		parts = append(parts, fmt.Sprintf("%s: %d", t.name, t.count))
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeFileTypes(t *testing.T) {
+	types, err := mergeFileTypes([]fileType{
+		{Name: "images", Extensions: []string{"png"}},
+		{Name: "code", Extensions: []string{"go", "rs"}, Icon: "🧩"},
+		{Name: "dir", Extensions: []string{"d"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "dir") {
+		t.Errorf("a type named dir: err = %v, want it named", err)
+	}
+	for path, want := range map[string]string{"/x/a.png": "images", "/x/a.JPG": "", "/x/main.go": "code", "/x/a.pdf": "documents", "/x/conf.d": ""} {
+		got := ""
+		if ft := types.typeOf(path); ft != nil {
+			got = ft.Name
+		}
+		if got != want {
+			t.Errorf("typeOf(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if icon := extIcon("/x/main.go", types); icon != "🧩" {
+		t.Errorf("extIcon(main.go) = %q, want the code type's", icon)
+	}
+
+	// type: clauses go by the table the query was parsed with
+	if _, err := parseQuery("type:code", types); err != nil {
+		t.Errorf("type:code with it configured: %v", err)
+	}
+	if _, err := parseQuery("type:code", defaultFileTypes()); err == nil {
+		t.Error("type:code without it configured succeeded")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+)
+
+// screen is everything View draws, gathered from the model in one place;
+// renderScreen lays it out without looking at the model again.
+type screen struct {
+	width, height int
+	input         string // the query line as the text input draws it
+	underInput    string // the quick action buttons, or what's wrong with the query
+	table         string
+	status        []string // joined with dots
+	help          string
+	dialogs       []string // drawn in their frames, bottom of the stack first
+	toasts        string   // stacked in the top right corner, "" for none
+	suggestions   string   // the dropdown under the input, "" for none
+}
+
+func (m model) View() string {
+	return zone.Scan(renderScreen(m.screen()) + "\n")
+}
+
+func (m model) screen() screen {
+	s := screen{
+		width:       m.width,
+		height:      m.height,
+		input:       m.textInput.View(),
+		underInput:  m.underInput(),
+		table:       m.tableView(),
+		status:      m.status(),
+		help:        m.help.View(m.keys),
+		dialogs:     m.modals.views(m.width),
+		suggestions: m.suggestView(max(m.width/2, 20)),
+	}
+	if m.paneShown() {
+		s.table = m.splitView(s.table)
+	}
+	if len(m.toasts.items) > 0 {
+		s.toasts = m.toasts.render(max(m.width/2, 20))
+	}
+	return s
+}
+
+// renderScreen lays out a screen
+func renderScreen(s screen) string {
+	view := baseStyle.Width(s.width - 2).MaxWidth(s.width).Render(
+		s.input + "\n" + s.underInput + "\n" + s.table + "\n\n" + strings.Join(s.status, " · ") + "\n" + s.help,
+	)
+	if s.suggestions != "" { // over the buttons and table, just under the query
+		view = placeOverlay(2, 2, s.suggestions, view)
+	}
+	view = placeDialogs(view, s.dialogs, s.width, s.height)
+	if s.toasts != "" { // over the top right corner of the table
+		view = placeOverlay(max(s.width-1-lipgloss.Width(s.toasts), 1), 3, s.toasts, view)
+	}
+	return view
+}
+
+// underInput is the quick actions bar, or what's wrong with the query while there's an error
+func (m model) underInput() string {
+	if m.queryErr != "" && m.searchQuery != "" {
+		return queryErrStyle.Render("✗ " + m.queryErr)
+	}
+	return m.actionBar()
+}
+
+var queryErrStyle = lipgloss.NewStyle().Foreground(toastColors[toastError])
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files under testdata")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	lipgloss.SetColorProfile(termenv.Ascii) // the layout without escapes, whatever runs the tests
+	zone.NewGlobal()
+	os.Exit(m.Run())
+}
+
+// fixtureModel is the UI as gocate -fake-backend testdata/fixture.json starts
+// it, with the config's defaults and searches running as soon as they're typed
+func fixtureModel(t *testing.T) model {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	fx, err := loadFixture(filepath.Join("testdata", "fixture.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, savedLive, savedStat := backend, liveBackend, statPath
+	t.Cleanup(func() { backend, liveBackend, statPath = saved, savedLive, savedStat })
+	useFixture(fx)
+
+	m := newModel("/home/ann")
+	m.caps = capabilities{null: true, regex: true, basename: true} // what probeCapabilities says of a fixture, without the helpers this machine has
+	cfg := defaultConfig()
+	cfg.Searches = []savedSearch{{Name: "pdfs", Query: "report ext:pdf"}}
+	m.applyConfig(cfg)
+	return m
+}
+
+// drive feeds msgs to m the way the program would, running the commands
+// Update returns and feeding back what they send. Those still waiting after
+// a moment are timers, like cursor blinks and toasts expiring, and are dropped.
+// Jobs don't hold off for the keys, they're all sent at once.
+func drive(m model, msgs ...tea.Msg) model {
+	update := func(msg tea.Msg) tea.Cmd {
+		next, cmd := m.Update(msg)
+		m = next.(model)
+		m.jobs.typed.Store(0)
+		return cmd
+	}
+	var run func(cmd tea.Cmd, depth int)
+	run = func(cmd tea.Cmd, depth int) {
+		if cmd == nil || depth > 8 {
+			return
+		}
+		sent := make(chan tea.Msg, 1)
+		go func() { sent <- cmd() }()
+		var msg tea.Msg
+		select {
+		case msg = <-sent:
+		case <-time.After(50 * time.Millisecond):
+			return
+		}
+		switch msg := msg.(type) {
+		case nil:
+			return
+		case tea.BatchMsg:
+			for _, c := range msg {
+				run(c, depth+1)
+			}
+			return
+		case searchResultsMsg:
+			msg.elapsed = 12 * time.Millisecond // the status line says how long it took
+			run(update(msg), depth+1)
+			return
+		}
+		run(update(msg), depth+1)
+	}
+	for _, msg := range msgs {
+		run(update(msg), 0)
+	}
+	return m
+}
+
+// typed is the key presses for s
+func typed(s string) []tea.Msg {
+	msgs := make([]tea.Msg, 0, len(s))
+	for _, r := range s {
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+func TestView(t *testing.T) {
+	sizes := []struct{ width, height int }{{80, 24}, {120, 32}, {50, 14}}
+	for _, size := range sizes {
+		cases := []struct {
+			name string
+			keys []tea.Msg
+		}{
+			{"results", typed("docs")},
+			{"dialog", append(typed("docs"), tea.KeyMsg{Type: tea.KeyF1})},
+			{"toasts", append(typed("docs"), tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}, Alt: true})},
+			{"suggestions", typed("rep")},
+		}
+		for _, c := range cases {
+			name := fmt.Sprintf("%s-%dx%d", c.name, size.width, size.height)
+			t.Run(name, func(t *testing.T) {
+				m := drive(fixtureModel(t), append([]tea.Msg{tea.WindowSizeMsg{Width: size.width, Height: size.height}}, c.keys...)...)
+				golden(t, name, zone.Scan(m.View()))
+			})
+		}
+	}
+}
+
+// golden compares got with testdata/name.golden, rewriting it with -update
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s doesn't match:\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// walkPrune are the directories updatedb leaves out by default, which only hold
+// kernel and runtime state and are slow or endless to read
+var walkPrune = map[string]bool{"/proc": true, "/sys": true, "/dev": true, "/run": true}
+
+// walkSearcher reads the filesystem itself on every search, for machines with
+// no locate installed. A few directories are read at a time, as deep as the
+// config allows, without going into ignored directories or following symlinks.
+type walkSearcher struct{}
+
+// walkJob is a directory waiting to be read, depth levels below the root
+type walkJob struct {
+	dir   string
+	depth int
+}
+
+// Search streams matches as the directories are read, so the order is
+// roughly breadth first. Directories that can't be read are skipped like
+// updatedb does, not reported.
+func (walkSearcher) Search(ctx context.Context, patterns []string, opts searchOpts) (<-chan result, error) {
+	match, err := pathMatcher(patterns, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := make(chan result)
+	go func() {
+		defer close(results)
+		var (
+			mu      sync.Mutex
+			ready   = sync.NewCond(&mu)
+			queue   = []walkJob{{cmp.Or(opts.root, "/"), 1}}
+			pending = 1 // jobs queued or being read
+			wg      sync.WaitGroup
+		)
+		// read lists one directory, sending its matches and returning the
+		// directories to read next. Once cancelled it only drains the queue.
+		read := func(job walkJob) []walkJob {
+			if ctx.Err() != nil {
+				return nil
+			}
+			entries, err := os.ReadDir(job.dir)
+			if err != nil {
+				return nil
+			}
+			var next []walkJob
+			for _, e := range entries {
+				if ignoredName(opts.ignore, e.Name()) {
+					continue
+				}
+				path := filepath.Join(job.dir, e.Name())
+				if match(path) {
+					select {
+					case results <- result{path: path}:
+					case <-ctx.Done():
+						return nil
+					}
+				}
+				if e.IsDir() && !walkPrune[path] && (opts.maxDepth == 0 || job.depth < opts.maxDepth) {
+					next = append(next, walkJob{path, job.depth + 1})
+				}
+			}
+			return next
+		}
+		for range runtime.NumCPU() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					mu.Lock()
+					for len(queue) == 0 && pending > 0 {
+						ready.Wait()
+					}
+					if pending == 0 {
+						mu.Unlock()
+						return
+					}
+					job := queue[0]
+					queue = queue[1:]
+					mu.Unlock()
+
+					next := read(job)
+					mu.Lock()
+					queue = append(queue, next...)
+					pending += len(next) - 1
+					mu.Unlock()
+					ready.Broadcast()
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+	return results, nil
+}
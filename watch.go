@@ -0,0 +1,232 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watching runs the query again every watch_seconds, and as soon as something
+// changes under the root when there is one, highlighting the results that
+// weren't there before, e.g. while waiting for a build or a download to turn
+// up. The locate database only changes when updatedb runs, so new files only
+// show with live search, the walker, or gocate's index with watch_index.
+
+// defaultWatchSeconds is how often a watched query runs again unless configured
+const defaultWatchSeconds = 5
+
+// watchDirLimit is how many directories under the root are followed, the
+// interval catching changes deeper down
+const watchDirLimit = 2000
+
+// watchState is the watch toggle and what it has seen so far
+type watchState struct {
+	on    bool
+	seq   int            // tells the ticks and events of an earlier watch apart
+	seen  map[rowID]bool // every result shown since the query last changed
+	fresh map[rowID]bool // the results that turned up while watching
+	rerun string         // the query the watch is searching for again, "" when it isn't
+	stop  func()         // stops following the root, nil without one
+}
+
+var freshStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true)
+
+// watchTickMsg runs a watched query again
+type watchTickMsg struct {
+	seq int
+}
+
+// watchEventMsg is a batch of changes under the watched root
+type watchEventMsg struct {
+	seq    int
+	events <-chan struct{}
+}
+
+// watchSeconds is how often a watched query runs again
+func (m model) watchSeconds() int {
+	return cmp.Or(max(m.cfg.WatchSecs, 0), defaultWatchSeconds)
+}
+
+func (m model) watchTick() tea.Cmd {
+	seq := m.watch.seq
+	return tea.Tick(time.Duration(m.watchSeconds())*time.Second, func(time.Time) tea.Msg { return watchTickMsg{seq} })
+}
+
+// waitWatchEvent reads the next batch of changes, nothing once the watch stopped
+func waitWatchEvent(seq int, events <-chan struct{}) tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-events; !ok {
+			return nil
+		}
+		return watchEventMsg{seq, events}
+	}
+}
+
+// toggleWatch starts or stops watching the current query
+func (m *model) toggleWatch() tea.Cmd {
+	if m.watch.on {
+		m.stopWatch()
+		return notify(toastInfo, "Stopped watching")
+	}
+	if m.searchQuery == "" {
+		return notify(toastWarn, "Type a query to watch")
+	}
+	m.watch = watchState{on: true, seq: m.watch.seq + 1, seen: map[rowID]bool{}, fresh: map[rowID]bool{}}
+	for _, row := range m.results {
+		m.watch.seen[idOf(row)] = true
+	}
+	cmds := []tea.Cmd{m.watchTick()}
+	text := fmt.Sprintf("Searching again every %ds", m.watchSeconds())
+	if m.root != "" && m.remote == "" {
+		events, stop, err := watchTree(m.root, m.cfg.Ignore)
+		if err == nil {
+			m.watch.stop = stop
+			cmds = append(cmds, waitWatchEvent(m.watch.seq, events))
+			text += " and on changes under " + m.displayRoot()
+		}
+	}
+	if !m.findsNewFiles() {
+		cmds = append(cmds, notify(toastWarn, text+", though the locate database only changes with updatedb"))
+	} else {
+		cmds = append(cmds, notify(toastInfo, text))
+	}
+	m.refreshKeys()
+	return tea.Batch(cmds...)
+}
+
+func (m *model) stopWatch() {
+	if m.watch.stop != nil {
+		m.watch.stop()
+	}
+	m.watch = watchState{seq: m.watch.seq}
+	m.refreshKeys()
+}
+
+// findsNewFiles reports whether searching again can find files created since
+// the search started, rather than what the last updatedb saw
+func (m model) findsNewFiles() bool {
+	if m.live || m.content {
+		return true
+	}
+	if m.remote != "" {
+		return false
+	}
+	switch backend.(type) {
+	case walkSearcher:
+		return true
+	case indexSearcher, daemonSearcher:
+		return m.cfg.WatchIndex
+	}
+	return false
+}
+
+// rerunWatched searches again for a watch tick or a change under the root,
+// unless the last one is still running
+func (m *model) rerunWatched(seq int) tea.Cmd {
+	if !m.watch.on || seq != m.watch.seq || m.watch.rerun == m.searchQuery || m.searchQuery == "" {
+		return nil
+	}
+	m.watch.rerun = m.searchQuery
+	return m.search()
+}
+
+// noteWatched highlights the results a watched search found that weren't
+// there before. A new query starts over, and rows loaded by scrolling down
+// aren't new, only newly shown.
+func (m *model) noteWatched(query string, rows []table.Row, sameQuery bool) {
+	rerun := m.watch.rerun == query
+	m.watch.rerun = ""
+	if !m.watch.on {
+		return
+	}
+	if !sameQuery {
+		m.watch.seen, m.watch.fresh = map[rowID]bool{}, map[rowID]bool{}
+	}
+	for _, row := range rows {
+		id := idOf(row)
+		if rerun && sameQuery && !m.watch.seen[id] {
+			m.watch.fresh[id] = true
+		}
+		m.watch.seen[id] = true
+	}
+}
+
+// watchStatus is the footer's note while watching, "" otherwise
+func (m model) watchStatus() string {
+	if !m.watch.on {
+		return ""
+	}
+	if n := len(m.watch.fresh); n > 0 {
+		return fmt.Sprintf("watching, %d new", n)
+	}
+	return "watching"
+}
+
+// watchTree follows the directories under root, up to watchDirLimit of them,
+// sending on events once a burst of changes has settled. stop ends it and
+// closes events.
+func watchTree(root string, ignore []string) (events <-chan struct{}, stop func(), err error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, err
+	}
+	watched := 0
+	add := func(dir string) {
+		if watched < watchDirLimit && !walkPrune[dir] && w.Add(dir) == nil {
+			watched++
+		}
+	}
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != root && ignoredName(ignore, d.Name()) || walkPrune[path] {
+			return filepath.SkipDir
+		}
+		add(path)
+		if watched >= watchDirLimit {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer close(ch)
+		var settle <-chan time.Time
+		for {
+			select {
+			case e, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if e.Has(fsnotify.Create) && !ignoredName(ignore, filepath.Base(e.Name)) {
+					if info, err := os.Lstat(e.Name); err == nil && info.IsDir() {
+						add(e.Name)
+					}
+				}
+				if settle == nil {
+					settle = time.After(indexWatchDelay)
+				}
+			case _, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+			case <-settle:
+				settle = nil
+				select {
+				case ch <- struct{}{}:
+				default: // the model hasn't read the last one yet
+				}
+			}
+		}
+	}()
+	return ch, func() { w.Close() }, nil
+}